@@ -1,141 +1,168 @@
-package main
-
-import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"path/filepath"
-	"strconv"
-	"strings"
-
-	"github.com/WilliwadelmaWisky/DatabaseSQL/sql"
-)
-
-// Main function
-func main() {
-	if len(os.Args) <= 1 {
-		fmt.Println("No arguments were given to the script! Use --help to get more information.")
-		return
-	}
-
-	if os.Args[1] == "-h" || os.Args[1] == "--help" {
-		fmt.Println("Print help")
-		return
-	}
-
-	if os.Args[1] == "-v" || os.Args[1] == "--version" {
-		fmt.Println("Print version")
-		return
-	}
-
-	url := os.Args[1]
-	port := 9000
-	if len(os.Args) > 2 {
-		value, err := strconv.Atoi(os.Args[2])
-		if err != nil {
-			fmt.Printf("%s\n", err.Error())
-			return
-		}
-
-		port = value
-	}
-
-	homeDir, _ := os.UserHomeDir()
-	path := filepath.Join(append([]string{homeDir, ".WilliwadelmaWisky", "DatabaseSQL"}, strings.Split(url, "/")...)...)
-	err := os.MkdirAll(path, os.ModePerm)
-	if err != nil {
-		fmt.Printf("%s\n", err.Error())
-	}
-
-	database := sql.NewDatabase(path)
-	err = database.Load()
-	if err != nil {
-		fmt.Printf("%s\n", err.Error())
-	}
-
-	server := &sql.Server{
-		Addr: fmt.Sprintf("localhost:%d", port),
-		Routes: []sql.Route{
-			{
-				URI:        "/",
-				MethodFlag: sql.HTTP_POST,
-				Handler:    func(w http.ResponseWriter, r *http.Request) { sqlRequestHandler(w, r, database) },
-			},
-			{
-				URI:        "/information_schema",
-				MethodFlag: sql.HTTP_GET,
-				Handler:    func(w http.ResponseWriter, r *http.Request) { informationSchemaRequestHandler(w, r, database) },
-			},
-		},
-	}
-
-	fmt.Printf("Server starting at http://localhost:%d/\nPress <CTRL+C> to terminate!\n", port)
-	server.ListenAndServe()
-}
-
-// HttpServer request handler for sql requests
-func sqlRequestHandler(w http.ResponseWriter, r *http.Request, database *sql.Database) {
-	bytes, _ := io.ReadAll(r.Body)
-	fmt.Printf("[SQL]: %s\n", string(bytes))
-
-	tokens := sql.Tokenize(bytes)
-	if len(tokens) == 0 {
-		fmt.Print("[ERROR]: No tokens received from request\n")
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	// TOKEN DEBUGGING
-	// values := sql.Map(tokens, func(token *sql.Token) string {
-	// 	return fmt.Sprintf("'%s'", token.Value)
-	// })
-	// fmt.Printf("Tokens found: %s\n", strings.Join(values, " "))
-
-	operation, err := sql.Parse(tokens)
-	if err != nil {
-		fmt.Printf("[ERROR]: %s\n", err.Error())
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	result, err := operation.Call(database)
-	if err != nil {
-		fmt.Printf("[ERROR]: %s\n", err.Error())
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	if result != nil {
-		w.Header().Add("Content-Length", strconv.Itoa(len(result)))
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write(result)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-}
-
-// HttpServer request handler for information_schema requests
-func informationSchemaRequestHandler(w http.ResponseWriter, r *http.Request, database *sql.Database) {
-	informationSchema := sql.NewInformationSchema(database)
-	bytes, err := json.Marshal(informationSchema)
-
-	if err != nil {
-		fmt.Printf("[ERROR]: %s\n", err.Error())
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	if bytes != nil {
-		w.Header().Add("Content-Length", strconv.Itoa(len(bytes)))
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write(bytes)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-}
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WilliwadelmaWisky/DatabaseSQL/sql"
+)
+
+// Main function
+func main() {
+	if len(os.Args) <= 1 {
+		fmt.Println("No arguments were given to the script! Use --help to get more information.")
+		return
+	}
+
+	if os.Args[1] == "-h" || os.Args[1] == "--help" {
+		fmt.Println("Print help")
+		return
+	}
+
+	if os.Args[1] == "-v" || os.Args[1] == "--version" {
+		fmt.Println("Print version")
+		return
+	}
+
+	url := os.Args[1]
+	port := 9000
+	if len(os.Args) > 2 {
+		value, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			fmt.Printf("%s\n", err.Error())
+			return
+		}
+
+		port = value
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	path := filepath.Join(append([]string{homeDir, ".WilliwadelmaWisky", "DatabaseSQL"}, strings.Split(url, "/")...)...)
+	err := os.MkdirAll(path, os.ModePerm)
+	if err != nil {
+		fmt.Printf("%s\n", err.Error())
+	}
+
+	database := sql.NewDatabase(path)
+	err = database.Load()
+	if err != nil {
+		fmt.Printf("%s\n", err.Error())
+	}
+
+	transactions := sql.NewTransactionManager(database)
+	go func() {
+		for range time.Tick(time.Minute) {
+			transactions.RemoveExpired()
+		}
+	}()
+
+	server := &sql.Server{
+		Addr: fmt.Sprintf("localhost:%d", port),
+		Routes: []sql.Route{
+			{
+				URI:        "/",
+				MethodFlag: sql.HTTP_POST,
+				Handler:    func(w http.ResponseWriter, r *http.Request) { sqlRequestHandler(w, r, transactions) },
+			},
+			{
+				URI:        "/information_schema",
+				MethodFlag: sql.HTTP_GET,
+				Handler:    func(w http.ResponseWriter, r *http.Request) { informationSchemaRequestHandler(w, r, database) },
+			},
+		},
+	}
+
+	fmt.Printf("Server starting at http://localhost:%d/\nPress <CTRL+C> to terminate!\n", port)
+	server.ListenAndServe()
+}
+
+// Http header to bind requests to a transaction, the id is returned in the header of the response to begin
+const transactionHeader = "X-Transaction-Id"
+
+// Http header to execute the statements of a request atomically, the value must be true to enable
+const atomicHeader = "X-Atomic"
+
+// HttpServer request handler for sql requests.
+// A request can have many statements separated by semicolons, the response is an array of the results if there is more than one statement.
+// Requests with a transaction id header are executed in the transaction
+func sqlRequestHandler(w http.ResponseWriter, r *http.Request, transactions *sql.TransactionManager) {
+	bytes, _ := io.ReadAll(r.Body)
+	fmt.Printf("[SQL]: %s\n", string(bytes))
+
+	operations, err := sql.ParseScript(sql.Tokenize(bytes))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	atomic, _ := strconv.ParseBool(r.Header.Get(atomicHeader))
+	results, transactionId, err := transactions.ExecuteScript(operations, r.Header.Get(transactionHeader), atomic)
+	w.Header().Add("Access-Control-Expose-Headers", transactionHeader)
+	if transactionId != "" {
+		w.Header().Add(transactionHeader, transactionId)
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := results[0]
+	if len(results) > 1 {
+		result, err = json.Marshal(sql.Map(results, func(result []byte) json.RawMessage { return result }))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	if result != nil {
+		w.Header().Add("Content-Length", strconv.Itoa(len(result)))
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(result)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// HttpServer request handler for information_schema requests
+func informationSchemaRequestHandler(w http.ResponseWriter, r *http.Request, database *sql.Database) {
+	informationSchema := sql.NewInformationSchema(database)
+	bytes, err := json.Marshal(informationSchema)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if bytes != nil {
+		w.Header().Add("Content-Length", strconv.Itoa(len(bytes)))
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(bytes)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Write an error response, the body is a json object of the error code, the message, the statement and the token position.
+// The status code depends on the category of the error
+func writeError(w http.ResponseWriter, err error) {
+	fmt.Printf("[ERROR]: %s\n", err.Error())
+	sqlError := sql.GetError(err)
+	bytes, _ := json.Marshal(sqlError)
+	w.Header().Add("Content-Length", strconv.Itoa(len(bytes)))
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(sqlError.Category.StatusCode())
+	w.Write(bytes)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WilliwadelmaWisky/DatabaseSQL/sql"
+)
+
+func TestSqlRequestHandlerConcurrent(t *testing.T) {
+	database := sql.NewDatabase(t.TempDir())
+	err := database.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions := sql.NewTransactionManager(database)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { sqlRequestHandler(w, r, transactions) }))
+	defer server.Close()
+
+	var response *http.Response
+	post := func(body string, transactionId string) (*http.Response, error) {
+		request, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		if transactionId != "" {
+			request.Header.Add(transactionHeader, transactionId)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return nil, err
+		}
+
+		response.Body.Close()
+		return response, nil
+	}
+
+	response, err = post("CREATE TABLE artists (id INT, name VARCHAR)", "")
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatal("create table request failed")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, body := range []string{
+				fmt.Sprintf("INSERT INTO artists (id, name) VALUES (%d, 'Artist %d')", i, i),
+				"SELECT * FROM artists WHERE id < 25 ORDER BY name ASC",
+				fmt.Sprintf("UPDATE artists SET name = 'Updated' WHERE id = %d", i),
+				"SELECT COUNT(*) FROM artists",
+			} {
+				response, err := post(body, "")
+				if err != nil || response.StatusCode != http.StatusOK {
+					t.Errorf("request failed: %s", body)
+				}
+			}
+
+			response, err := post("BEGIN", "")
+			if err != nil || response.StatusCode != http.StatusOK {
+				t.Error("begin request failed")
+				return
+			}
+
+			transactionId := response.Header.Get(transactionHeader)
+			post(fmt.Sprintf("DELETE FROM artists WHERE id = %d", i), transactionId)
+			post("ROLLBACK", transactionId)
+		}()
+	}
+
+	wg.Wait()
+
+	table, err := database.Get("artists")
+	if err != nil || len(table.Columns[0].Values) != 50 {
+		t.Fatal("every inserted row should be in the table")
+	}
+}
+
+func TestSqlRequestHandlerError(t *testing.T) {
+	database := sql.NewDatabase(t.TempDir())
+	err := database.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions := sql.NewTransactionManager(database)
+	tests := []struct {
+		body     string
+		status   int
+		code     string
+		position any
+	}{
+		{"CREATE TABLE artists (id INT UNIQUE)", http.StatusOK, "", nil},
+		{"INSERT INTO artists VALUES (1)", http.StatusOK, "", nil},
+		{"SELECT * FROM artists WHERE id = 1 SORT", http.StatusBadRequest, "syntax", float64(8)},
+		{"SELECT * FROM albums", http.StatusNotFound, "not_found", nil},
+		{"INSERT INTO artists VALUES (1)", http.StatusConflict, "constraint", nil},
+		{"INSERT INTO artists VALUES ('a')", http.StatusBadRequest, "type", float64(5)},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		sqlRequestHandler(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body)), transactions)
+		if recorder.Code != test.status {
+			t.Fatalf("wrong status for %s, expected=%d, got=%d", test.body, test.status, recorder.Code)
+		}
+
+		if test.code == "" {
+			continue
+		}
+
+		body := map[string]any{}
+		err := json.Unmarshal(recorder.Body.Bytes(), &body)
+		if err != nil || body["code"] != test.code || body["message"] == "" || body["position"] != test.position {
+			t.Fatalf("wrong error body for %s: %s", test.body, recorder.Body.String())
+		}
+	}
+}
+
+func TestSqlRequestHandlerScript(t *testing.T) {
+	database := sql.NewDatabase(t.TempDir())
+	err := database.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions := sql.NewTransactionManager(database)
+	post := func(body string, atomic bool) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if atomic {
+			request.Header.Add(atomicHeader, "true")
+		}
+
+		sqlRequestHandler(recorder, request, transactions)
+		return recorder
+	}
+
+	recorder := post("CREATE TABLE artists (id INT); INSERT INTO artists VALUES (1); SELECT id FROM artists;", false)
+	results := []json.RawMessage{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &results)
+	if recorder.Code != http.StatusOK || err != nil || len(results) != 3 || string(results[0]) != "null" {
+		t.Fatalf("script should have returned the results of every statement: %s", recorder.Body.String())
+	}
+
+	recorder = post("INSERT INTO artists VALUES (2); INSERT INTO artists VALUES ('a')", true)
+	body := map[string]any{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &body)
+	if recorder.Code != http.StatusBadRequest || err != nil || body["code"] != "type" || body["statement"] != float64(2) {
+		t.Fatalf("wrong error body for the failed statement: %s", recorder.Body.String())
+	}
+
+	recorder = post("SELECT id FROM artists", false)
+	data := &sql.TableData{}
+	err = json.Unmarshal(recorder.Body.Bytes(), data)
+	if err != nil || len(data.Data) != 1 {
+		t.Fatalf("atomic script should not have saved any changes: %s", recorder.Body.String())
+	}
+}
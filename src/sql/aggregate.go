@@ -0,0 +1,175 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Enum to represent an aggregate function of a select list, values are prefixed with AGGREGATE
+type AggregateFunction int
+
+const (
+	AGGREGATE_NONE  AggregateFunction = iota // No aggregate, the plain column value
+	AGGREGATE_COUNT                          // Number of rows
+	AGGREGATE_SUM                            // Sum of the values
+	AGGREGATE_AVG                            // Average of the values
+	AGGREGATE_MIN                            // Smallest value
+	AGGREGATE_MAX                            // Largest value
+)
+
+// Get an aggregate function from a string (not casesensitive)
+func GetAggregateFunction(s string) (AggregateFunction, error) {
+	switch strings.ToUpper(s) {
+	case "COUNT":
+		return AGGREGATE_COUNT, nil
+	case "SUM":
+		return AGGREGATE_SUM, nil
+	case "AVG":
+		return AGGREGATE_AVG, nil
+	case "MIN":
+		return AGGREGATE_MIN, nil
+	case "MAX":
+		return AGGREGATE_MAX, nil
+	}
+
+	return -1, fmt.Errorf("invalid aggregate function: %s", s)
+}
+
+// Get a string value of an aggregate function
+func (function AggregateFunction) ToString() string {
+	switch function {
+	case AGGREGATE_COUNT:
+		return "COUNT"
+	case AGGREGATE_SUM:
+		return "SUM"
+	case AGGREGATE_AVG:
+		return "AVG"
+	case AGGREGATE_MIN:
+		return "MIN"
+	case AGGREGATE_MAX:
+		return "MAX"
+	}
+
+	return ""
+}
+
+// Represents a single item of a select list, a column optionally wrapped in an aggregate function
+type Projection struct {
+	ColumnName string            // Column name, * for all columns or all rows in COUNT(*)
+	Function   AggregateFunction // Aggregate function applied to the column
+	Token      *Token            // Token of the column or the function in the query, nil if the projection was not parsed
+}
+
+// Get the result column name of the projection, for example COUNT(*)
+func (projection *Projection) Name() string {
+	if projection.Function == AGGREGATE_NONE {
+		return projection.ColumnName
+	}
+
+	return fmt.Sprintf("%s(%s)", projection.Function.ToString(), projection.ColumnName)
+}
+
+// Check if the projection is an aggregate function
+func (projection *Projection) IsAggregate() bool {
+	return projection.Function != AGGREGATE_NONE
+}
+
+// Get the columns of a table by the result column names of projections, a single asterisk gets all columns.
+// Unknown columns are errors located at the token of the projection
+func getProjectionColumns(table *Table, projections []*Projection) ([]*Column, error) {
+	if len(projections) == 1 && projections[0].Name() == "*" {
+		return table.Columns, nil // SELECT ALL
+	}
+
+	columns := []*Column{}
+	for _, projection := range projections {
+		col, err := table.getColumnByName(projection.Name())
+		if err != nil {
+			return nil, locateError(err, projection.Token)
+		}
+
+		columns = append(columns, col)
+	}
+
+	return columns, nil
+}
+
+// Get the result type of the aggregate function over a table
+func (projection *Projection) getType(table *Table) (ColumnType, error) {
+	_, t, err := projection.aggregate(table, []int{})
+	return t, err
+}
+
+// Evaluate the aggregate function over the rows of a table.
+// Null values are ignored, functions other than count are null if there are no values
+func (projection *Projection) aggregate(table *Table, rowIndexes []int) (Value, ColumnType, error) {
+	if projection.Function == AGGREGATE_COUNT && projection.ColumnName == "*" {
+		return NewValue(strconv.Itoa(len(rowIndexes))), TYPE_INT, nil
+	}
+
+	col, err := table.getColumnByName(projection.ColumnName)
+	if err != nil {
+		return Value{}, -1, err
+	}
+
+	values := []Value{}
+	for _, rowIndex := range rowIndexes {
+		if !col.Values[rowIndex].IsNull() {
+			values = append(values, col.Values[rowIndex])
+		}
+	}
+
+	switch projection.Function {
+	case AGGREGATE_COUNT:
+		return NewValue(strconv.Itoa(len(values))), TYPE_INT, nil
+	case AGGREGATE_SUM, AGGREGATE_AVG:
+		if !col.Type.IsNumeric() {
+			return Value{}, -1, newError(ERROR_TYPE, "%s requires a numeric column: %s", projection.Function.ToString(), col.Name)
+		}
+
+		resultType := TYPE_FLOAT
+		if projection.Function == AGGREGATE_SUM {
+			resultType = col.Type
+		}
+
+		if len(values) == 0 {
+			return Value{}, resultType, nil
+		}
+
+		sum := 0.0
+		intSum := 0
+		for _, value := range values {
+			floatValue, _ := strconv.ParseFloat(value.String, 64)
+			intValue, _ := strconv.Atoi(value.String)
+			sum += floatValue
+			intSum += intValue
+		}
+
+		if projection.Function == AGGREGATE_AVG {
+			return NewValue(FormatFloat(sum / float64(len(values)))), resultType, nil
+		}
+
+		if col.Type == TYPE_INT {
+			return NewValue(strconv.Itoa(intSum)), resultType, nil
+		}
+
+		return NewValue(FormatFloat(sum)), resultType, nil
+	case AGGREGATE_MIN, AGGREGATE_MAX:
+		if len(values) == 0 {
+			return Value{}, col.Type, nil
+		}
+
+		result := values[0]
+		for _, value := range values[1:] {
+			diff := Compare(col.Type, value, result)
+			if (projection.Function == AGGREGATE_MIN && diff < 0) || (projection.Function == AGGREGATE_MAX && diff > 0) {
+				result = value
+			}
+		}
+
+		return result, col.Type, nil
+	}
+
+	return Value{}, -1, fmt.Errorf("invalid aggregate function")
+}
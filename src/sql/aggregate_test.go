@@ -0,0 +1,48 @@
+package sql
+
+import (
+	"testing"
+)
+
+func TestSelectAggregate(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "t", Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: NewValues("1", "2", "4")}}})
+	data, err := selectData(t, database, "SELECT COUNT(*), SUM(col1), AVG(col1), MAX(col1) FROM t")
+	if err != nil {
+		t.Fatal("aggregate returned an error but should not have")
+	}
+
+	expected := []string{"3", "7", "2.3333333333333335", "4"}
+	for i, value := range expected {
+		if data.Data[0][i].String != value {
+			t.Fatalf("wrong value for %s, expected=%s, got=%s", data.Columns[i], value, data.Data[0][i].String)
+		}
+	}
+
+	if data.ColumnTypes[2] != "FLOAT" {
+		t.Fatal("wrong type for avg, expected float")
+	}
+}
+
+func TestSelectAggregatePlainColumn(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "t", Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: NewValues("1")}}})
+	_, err := selectData(t, database, "SELECT col1, COUNT(*) FROM t")
+	if err == nil {
+		t.Fatal("error was not thrown but should have")
+	}
+}
+
+func TestSelectGroupHaving(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "t", Columns: []*Column{
+		{Name: "dept", Type: TYPE_VARCHAR, Values: NewValues("a", "b", "a", "c", "a", "b")},
+		{Name: "salary", Type: TYPE_INT, Values: NewValues("1", "2", "4", "8", "16", "32")},
+	}})
+
+	data, err := selectData(t, database, "SELECT dept, SUM(salary) FROM t GROUP BY dept HAVING COUNT(*) > 1 ORDER BY SUM(salary) DESC")
+	if err != nil {
+		t.Fatal("group returned an error but should not have")
+	}
+
+	if len(data.Data) != 2 || data.Data[0][0].String != "b" || data.Data[0][1].String != "34" || data.Data[1][0].String != "a" || data.Data[1][1].String != "21" {
+		t.Fatalf("wrong groups, expected [[b 34] [a 21]], got %v", data.Data)
+	}
+}
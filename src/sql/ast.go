@@ -0,0 +1,323 @@
+package sql
+
+// Base contract of a statement of the syntax tree, statements are compiled to operations
+type Statement interface {
+	GetPosition() int // Index of the token the statement starts at
+	statementNode()
+}
+
+// Base contract of an expression of the syntax tree.
+// Expressions are compiled to conditions, value expressions, projections or values depending on where they are used
+type Expr interface {
+	GetPosition() int // Index of the token the expression was found at, used in the errors of the compiler
+	exprNode()
+}
+
+// Enum to represent a kind of a literal, values are prefixed with LITERAL
+type LiteralKind int
+
+const (
+	LITERAL_NULL   LiteralKind = iota // Null keyword
+	LITERAL_NUMBER                    // Integer or decimal number, a minus sign is included in the value
+	LITERAL_STRING                    // Value in single quotes
+)
+
+// Represents a select statement `SELECT projections FROM table [joins] [WHERE] [GROUP BY] [HAVING] [ORDER BY] [LIMIT] [OFFSET]`.
+// Many where and having clauses are combined with and
+type SelectStatement struct {
+	Position    int
+	Projections []Expr // Columns and aggregate functions, * is a column expression
+	From        *TableClause
+	Joins       []*JoinClause
+	Where       Expr // Nil if there is no where clause
+	GroupBy     []Expr
+	Having      Expr // Nil if there is no having clause
+	OrderBy     []*OrderClause
+	Limit       Expr // Nil if there is no limit
+	Offset      Expr // Nil if there is no offset
+}
+
+// Represents an insert statement of rows of values or of selected rows
+type InsertStatement struct {
+	Position  int
+	TableName string
+	Columns   []*ColumnExpr // Empty for all columns of the table
+	Rows      [][]Expr      // Rows of values, empty if the rows are selected
+	Select    *SelectStatement
+}
+
+// Represents an update statement, the assignments of both `SET a = 1` and `(a) VALUES (1)` syntax
+type UpdateStatement struct {
+	Position    int
+	TableName   string
+	Assignments []*AssignmentClause
+	Where       Expr // Nil if there is no where clause
+}
+
+// Represents a delete statement
+type DeleteStatement struct {
+	Position  int
+	TableName string
+	Where     Expr // Nil if there is no where clause
+}
+
+// Represents a create table statement
+type CreateTableStatement struct {
+	Position  int
+	TableName string
+	Columns   []*ColumnDefinition
+}
+
+// Represents a create index statement
+type CreateIndexStatement struct {
+	Position    int
+	IndexName   string
+	TableName   string
+	ColumnNames []string
+	Unique      bool
+}
+
+// Represents a drop table statement
+type DropTableStatement struct {
+	Position  int
+	TableName string
+}
+
+// Represents a drop index statement
+type DropIndexStatement struct {
+	Position  int
+	IndexName string
+}
+
+// Represents an explain statement of a select
+type ExplainStatement struct {
+	Position int
+	Select   *SelectStatement
+}
+
+// Represents a transaction statement `BEGIN`, `COMMIT` or `ROLLBACK`
+type TransactionControlStatement struct {
+	Position  int
+	Statement TransactionStatement
+}
+
+// Represents a table in the from clause with an optional alias
+type TableClause struct {
+	Position int
+	Name     string
+	Alias    string // Empty if not given
+}
+
+// Represents a single join in the from clause
+type JoinClause struct {
+	Position  int
+	Type      JoinType
+	Table     *TableClause
+	Condition Expr
+}
+
+// Represents a single column or aggregate function to order by
+type OrderClause struct {
+	Position   int
+	Expression Expr
+	Direction  SortDirection
+}
+
+// Represents a column definition of a create table statement, the type is checked when compiled
+type ColumnDefinition struct {
+	Position   int
+	Name       string
+	TypeName   string
+	PrimaryKey bool
+	NotNull    bool
+	Unique     bool
+	Default    Expr // Nil if there is no default value
+}
+
+// Represents an assignment of an update statement
+type AssignmentClause struct {
+	Position   int
+	ColumnName string
+	Expression Expr
+}
+
+// Represents a column name or * in an expression
+type ColumnExpr struct {
+	Position int
+	Name     string
+}
+
+// Represents a literal value in an expression
+type LiteralExpr struct {
+	Position int
+	Kind     LiteralKind
+	Value    Value
+}
+
+// Represents a function call in an expression, for example COUNT(*)
+type FunctionExpr struct {
+	Position int
+	Name     string
+	Argument Expr
+}
+
+// Represents a negated expression, for example -x
+type UnaryExpr struct {
+	Position int
+	Operand  Expr
+}
+
+// Represents two expressions combined with an arithmetic operator
+type BinaryExpr struct {
+	Position int
+	Operator ArithmeticOperator
+	Left     Expr
+	Right    Expr
+}
+
+// Represents a comparison of two expressions.
+// Comparisons can be chained, for example 0 < x < 1 is a comparison of which left side is a comparison
+type ComparisonExpr struct {
+	Position int
+	Operator EqualityOperator
+	Left     Expr
+	Right    Expr
+}
+
+// Represents two expressions combined with a logical operator
+type LogicalExpr struct {
+	Position int
+	Operator LogicalOperator
+	Left     Expr
+	Right    Expr
+}
+
+// Represents a negated condition
+type NotExpr struct {
+	Position int
+	Operand  Expr
+}
+
+// Represents an is null predicate `x IS [NOT] NULL`
+type IsNullExpr struct {
+	Position int
+	Operand  Expr
+	IsNot    bool
+}
+
+// Represents an in predicate `x [NOT] IN (a, b, c)`
+type InExpr struct {
+	Position int
+	Operand  Expr
+	Values   []Expr
+	IsNot    bool
+}
+
+// Represents a between predicate `x [NOT] BETWEEN a AND b`, both bounds are included
+type BetweenExpr struct {
+	Position int
+	Operand  Expr
+	Lower    Expr
+	Upper    Expr
+	IsNot    bool
+}
+
+// Represents a like predicate `x [NOT] LIKE 'pattern' [ESCAPE 'c']`
+type LikeExpr struct {
+	Position int
+	Operand  Expr
+	Pattern  Expr
+	Escape   Expr // Nil if there is no escape clause
+	IsNot    bool
+}
+
+// Get the index of the first token of the statement
+func (statement *SelectStatement) GetPosition() int { return statement.Position }
+
+// Get the index of the first token of the statement
+func (statement *InsertStatement) GetPosition() int { return statement.Position }
+
+// Get the index of the first token of the statement
+func (statement *UpdateStatement) GetPosition() int { return statement.Position }
+
+// Get the index of the first token of the statement
+func (statement *DeleteStatement) GetPosition() int { return statement.Position }
+
+// Get the index of the first token of the statement
+func (statement *CreateTableStatement) GetPosition() int { return statement.Position }
+
+// Get the index of the first token of the statement
+func (statement *CreateIndexStatement) GetPosition() int { return statement.Position }
+
+// Get the index of the first token of the statement
+func (statement *DropTableStatement) GetPosition() int { return statement.Position }
+
+// Get the index of the first token of the statement
+func (statement *DropIndexStatement) GetPosition() int { return statement.Position }
+
+// Get the index of the first token of the statement
+func (statement *ExplainStatement) GetPosition() int { return statement.Position }
+
+// Get the index of the first token of the statement
+func (statement *TransactionControlStatement) GetPosition() int { return statement.Position }
+
+// Get the index of the token of the expression
+func (expr *ColumnExpr) GetPosition() int { return expr.Position }
+
+// Get the index of the token of the expression
+func (expr *LiteralExpr) GetPosition() int { return expr.Position }
+
+// Get the index of the first token of the expression
+func (expr *FunctionExpr) GetPosition() int { return expr.Position }
+
+// Get the index of the first token of the expression
+func (expr *UnaryExpr) GetPosition() int { return expr.Position }
+
+// Get the index of the operator of the expression
+func (expr *BinaryExpr) GetPosition() int { return expr.Position }
+
+// Get the index of the operator of the expression
+func (expr *ComparisonExpr) GetPosition() int { return expr.Position }
+
+// Get the index of the operator of the expression
+func (expr *LogicalExpr) GetPosition() int { return expr.Position }
+
+// Get the index of the first token of the expression
+func (expr *NotExpr) GetPosition() int { return expr.Position }
+
+// Get the index of the is keyword of the expression
+func (expr *IsNullExpr) GetPosition() int { return expr.Position }
+
+// Get the index of the in keyword of the expression, or the not keyword before it
+func (expr *InExpr) GetPosition() int { return expr.Position }
+
+// Get the index of the between keyword of the expression, or the not keyword before it
+func (expr *BetweenExpr) GetPosition() int { return expr.Position }
+
+// Get the index of the like keyword of the expression, or the not keyword before it
+func (expr *LikeExpr) GetPosition() int { return expr.Position }
+
+// Statement and expression nodes are marked so that they cannot be used in place of each other
+func (*SelectStatement) statementNode()             {}
+func (*InsertStatement) statementNode()             {}
+func (*UpdateStatement) statementNode()             {}
+func (*DeleteStatement) statementNode()             {}
+func (*CreateTableStatement) statementNode()        {}
+func (*CreateIndexStatement) statementNode()        {}
+func (*DropTableStatement) statementNode()          {}
+func (*DropIndexStatement) statementNode()          {}
+func (*ExplainStatement) statementNode()            {}
+func (*TransactionControlStatement) statementNode() {}
+
+func (*ColumnExpr) exprNode()     {}
+func (*LiteralExpr) exprNode()    {}
+func (*FunctionExpr) exprNode()   {}
+func (*UnaryExpr) exprNode()      {}
+func (*BinaryExpr) exprNode()     {}
+func (*ComparisonExpr) exprNode() {}
+func (*LogicalExpr) exprNode()    {}
+func (*NotExpr) exprNode()        {}
+func (*IsNullExpr) exprNode()     {}
+func (*InExpr) exprNode()         {}
+func (*BetweenExpr) exprNode()    {}
+func (*LikeExpr) exprNode()       {}
@@ -0,0 +1,224 @@
+package sql
+
+import (
+	"slices"
+	"sort"
+)
+
+// Minimum number of children of a b-tree node other than the root, a node has at most 2*BTREE_DEGREE-1 items
+const BTREE_DEGREE = 32
+
+// An ordered tree of keys, each key has the indexes of the rows with the key.
+// Keys without rows are left in the tree and removed when the tree is rebuilt
+type btree struct {
+	root    *btreeNode
+	compare func(a []Value, b []Value) int // Compares keys, only the values present in both keys are compared
+	length  int                            // Number of keys with rows
+	empty   int                            // Number of keys without rows
+}
+
+// A single node of a b-tree, children[i] has the keys smaller than items[i]
+type btreeNode struct {
+	items    []*btreeItem
+	children []*btreeNode
+}
+
+// A single key of a b-tree
+type btreeItem struct {
+	key  []Value
+	rows []int
+}
+
+// A lower or upper limit of the keys visited in a b-tree, the key can be a prefix of the keys in the tree
+type btreeBound struct {
+	key       []Value
+	inclusive bool // Keys equal to the bound are visited
+}
+
+// Create a new empty b-tree
+func newBtree(compare func(a []Value, b []Value) int) *btree {
+	return &btree{root: &btreeNode{}, compare: compare}
+}
+
+// Find a key in the tree, returns nil if the key has no rows
+func (tree *btree) find(key []Value) *btreeItem {
+	node := tree.root
+	for {
+		i, found := node.search(tree, key)
+		if found {
+			if len(node.items[i].rows) == 0 {
+				return nil
+			}
+
+			return node.items[i]
+		}
+
+		if node.isLeaf() {
+			return nil
+		}
+
+		node = node.children[i]
+	}
+}
+
+// Add a row with a key to the tree
+func (tree *btree) add(key []Value, row int) {
+	node := tree.root
+	for {
+		i, found := node.search(tree, key)
+		if found {
+			if len(node.items[i].rows) == 0 {
+				tree.empty--
+				tree.length++
+			}
+
+			node.items[i].rows = append(node.items[i].rows, row)
+			return
+		}
+
+		if node.isLeaf() {
+			break
+		}
+
+		node = node.children[i]
+	}
+
+	if len(tree.root.items) == 2*BTREE_DEGREE-1 {
+		root := &btreeNode{children: []*btreeNode{tree.root}}
+		root.splitChild(0)
+		tree.root = root
+	}
+
+	tree.root.insert(tree, &btreeItem{key: key, rows: []int{row}})
+	tree.length++
+}
+
+// Remove a row with a key from the tree, the tree is rebuilt if most of the keys have no rows
+func (tree *btree) remove(key []Value, row int) {
+	item := tree.find(key)
+	if item == nil {
+		return
+	}
+
+	item.rows = slices.DeleteFunc(item.rows, func(r int) bool { return r == row })
+	if len(item.rows) > 0 {
+		return
+	}
+
+	tree.length--
+	tree.empty++
+	if tree.empty > BTREE_DEGREE && tree.empty > tree.length {
+		tree.rebuild()
+	}
+}
+
+// Move the rows after deleted rows to fill the deleted rows, the deleted rows must be sorted and not in the tree
+func (tree *btree) shift(deletedRows []int) {
+	tree.ascend(nil, nil, func(item *btreeItem) bool {
+		for i, row := range item.rows {
+			item.rows[i] = row - sort.SearchInts(deletedRows, row)
+		}
+
+		return true
+	})
+}
+
+// Visit the keys with rows between the bounds in ascending order until visit returns false, nil bounds are not checked
+func (tree *btree) ascend(lower *btreeBound, upper *btreeBound, visit func(item *btreeItem) bool) {
+	tree.root.ascend(tree, lower, upper, visit)
+}
+
+// Build the tree again without the keys that have no rows
+func (tree *btree) rebuild() {
+	items := []*btreeItem{}
+	tree.ascend(nil, nil, func(item *btreeItem) bool {
+		items = append(items, item)
+		return true
+	})
+
+	tree.root = &btreeNode{}
+	tree.length = 0
+	tree.empty = 0
+	for _, item := range items {
+		for _, row := range item.rows {
+			tree.add(item.key, row)
+		}
+	}
+}
+
+// Check if the node has no children
+func (node *btreeNode) isLeaf() bool {
+	return len(node.children) == 0
+}
+
+// Find the index of the first item with a key greater than or equal to the key
+func (node *btreeNode) search(tree *btree, key []Value) (int, bool) {
+	i := sort.Search(len(node.items), func(i int) bool { return tree.compare(node.items[i].key, key) >= 0 })
+	return i, i < len(node.items) && tree.compare(node.items[i].key, key) == 0
+}
+
+// Insert an item to a node that is not full
+func (node *btreeNode) insert(tree *btree, item *btreeItem) {
+	i, _ := node.search(tree, item.key)
+	if node.isLeaf() {
+		node.items = slices.Insert(node.items, i, item)
+		return
+	}
+
+	if len(node.children[i].items) == 2*BTREE_DEGREE-1 {
+		node.splitChild(i)
+		if tree.compare(item.key, node.items[i].key) > 0 {
+			i++
+		}
+	}
+
+	node.children[i].insert(tree, item)
+}
+
+// Split a full child of a node to two nodes, the middle item of the child is moved to the node
+func (node *btreeNode) splitChild(i int) {
+	child := node.children[i]
+	middle := child.items[BTREE_DEGREE-1]
+	right := &btreeNode{items: slices.Clone(child.items[BTREE_DEGREE:])}
+	child.items = child.items[:BTREE_DEGREE-1]
+	if !child.isLeaf() {
+		right.children = slices.Clone(child.children[BTREE_DEGREE:])
+		child.children = child.children[:BTREE_DEGREE]
+	}
+
+	node.items = slices.Insert(node.items, i, middle)
+	node.children = slices.Insert(node.children, i+1, right)
+}
+
+// Visit the keys of a node and its children, see btree.ascend.
+// Returns false if the visiting was stopped
+func (node *btreeNode) ascend(tree *btree, lower *btreeBound, upper *btreeBound, visit func(item *btreeItem) bool) bool {
+	for i, item := range node.items {
+		lowerComparison := 1
+		if lower != nil {
+			lowerComparison = tree.compare(item.key, lower.key)
+		}
+
+		if !node.isLeaf() && lowerComparison >= 0 && !node.children[i].ascend(tree, lower, upper, visit) {
+			return false
+		}
+
+		if upper != nil {
+			upperComparison := tree.compare(item.key, upper.key)
+			if upperComparison > 0 || upperComparison == 0 && !upper.inclusive {
+				return false
+			}
+		}
+
+		isAboveLower := lowerComparison > 0 || lowerComparison == 0 && lower.inclusive
+		if isAboveLower && len(item.rows) > 0 && !visit(item) {
+			return false
+		}
+	}
+
+	if !node.isLeaf() {
+		return node.children[len(node.items)].ascend(tree, lower, upper, visit)
+	}
+
+	return true
+}
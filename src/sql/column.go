@@ -1,8 +1,86 @@
-package sql
-
-// Represents a single column in a table
-type Column struct {
-	Name   string     `json:"column"` // Column name
-	Type   ColumnType `json:"type"`   // Column variable type
-	Values []string   `json:"values"` // Column data
-}
+package sql
+
+import (
+	"strconv"
+)
+
+// Represents a single column in a table
+type Column struct {
+	Name       string     `json:"column"`                // Column name
+	Type       ColumnType `json:"type"`                  // Column variable type
+	PrimaryKey bool       `json:"primary_key,omitempty"` // Column identifies a row, implies not null and unique
+	NotNull    bool       `json:"not_null,omitempty"`    // Column cannot contain null values
+	Unique     bool       `json:"unique,omitempty"`      // Column cannot contain the same value twice, nulls are allowed
+	Default    Value      `json:"default"`               // Value used when a value is not given on insert, null if not set
+	Values     []Value    `json:"values"`                // Column data, null values are written as null
+}
+
+// Check if the column can contain null values
+func (col *Column) IsNullable() bool {
+	return !col.NotNull && !col.PrimaryKey
+}
+
+// Check if the column can contain the same value only once
+func (col *Column) IsUnique() bool {
+	return col.Unique || col.PrimaryKey
+}
+
+// Convert a value to the type of the column, returns an error naming the column if the value is not valid
+func (col *Column) coerce(value Value) (Value, error) {
+	coerced, err := col.Type.Coerce(value)
+	if err != nil {
+		return Value{}, newError(ERROR_TYPE, "type mismatch in column %s of type %s: %s", col.Name, col.Type.ToString(), err.Error())
+	}
+
+	return coerced, nil
+}
+
+// Check that a value does not break the not null constraint of the column
+func (col *Column) checkNull(value Value) error {
+	if value.IsNull() && !col.IsNullable() {
+		return newError(ERROR_CONSTRAINT, "constraint violation: column %s cannot be null", col.Name)
+	}
+
+	return nil
+}
+
+// Check that new values do not break the unique constraint of the column, null values are not compared.
+// Existing values are the values of the rows that are kept, the set of those is built once for each check
+func (col *Column) checkUnique(existing []Value, newValues []Value) error {
+	if !col.IsUnique() || len(newValues) == 0 {
+		return nil
+	}
+
+	values := make(map[string]struct{}, len(existing)+len(newValues))
+	for _, value := range existing {
+		if !value.IsNull() {
+			values[col.uniqueKey(value)] = struct{}{}
+		}
+	}
+
+	for _, value := range newValues {
+		if value.IsNull() {
+			continue
+		}
+
+		key := col.uniqueKey(value)
+		if _, found := values[key]; found {
+			return newError(ERROR_CONSTRAINT, "constraint violation: duplicate value %s in unique column %s", value.String, col.Name)
+		}
+
+		values[key] = struct{}{}
+	}
+
+	return nil
+}
+
+// Get the key of a value in a unique set, values are normalized by the column type so negative zero equals zero
+func (col *Column) uniqueKey(value Value) string {
+	if col.Type == TYPE_FLOAT {
+		if floatValue, err := strconv.ParseFloat(value.String, 64); err == nil && floatValue == 0 {
+			return "0"
+		}
+	}
+
+	return value.String
+}
@@ -1,105 +1,194 @@
-package sql
-
-import (
-	"strconv"
-	"strings"
-)
-
-type EqualityOperator int
-
-const (
-	LESS             EqualityOperator = 1               // Less than operator <
-	GREATER          EqualityOperator = 2               // Greater than operator >
-	EQUAL            EqualityOperator = 4               // Equals to operator =
-	LESS_OR_EQUAL    EqualityOperator = LESS | EQUAL    // Less than or equals to operator <=
-	GREATER_OR_EQUAL EqualityOperator = GREATER | EQUAL // Greater than or equals to operator >=
-)
-
-// Get equality operator from string
-func GetEqualityOperator(s string) EqualityOperator {
-	switch s {
-	case "<":
-		return LESS
-	case ">":
-		return GREATER
-	case "=":
-		return EQUAL
-	case "<=":
-		return LESS_OR_EQUAL
-	case ">=":
-		return GREATER_OR_EQUAL
-	}
-
-	return -1
-}
-
-// Inverse of the equality operator, less than changes to greater than etc
-func (operator EqualityOperator) Inverse() EqualityOperator {
-	switch operator {
-	case LESS:
-		return GREATER
-	case LESS_OR_EQUAL:
-		return GREATER_OR_EQUAL
-	case EQUAL:
-		return EQUAL
-	case GREATER:
-		return LESS
-	case GREATER_OR_EQUAL:
-		return LESS_OR_EQUAL
-	}
-
-	return -1
-}
-
-// Compare values, based of type
-func (operator EqualityOperator) Compare(t ColumnType, a string, b string) bool {
-	switch t {
-	case TYPE_INT:
-		return operator.compareInt(a, b)
-	case TYPE_VARCHAR:
-		return operator.compareString(a, b)
-	}
-
-	return false
-}
-
-func Compare(t ColumnType, a string, b string) int {
-	switch t {
-	case TYPE_INT:
-		inta, _ := strconv.Atoi(a)
-		intb, _ := strconv.Atoi(b)
-		return inta - intb
-	case TYPE_VARCHAR:
-		return strings.Compare(a, b)
-	}
-
-	return 0
-}
-
-func (operator EqualityOperator) compareInt(a string, b string) bool {
-	intValue, _ := strconv.Atoi(a)
-	intCompareValue, _ := strconv.Atoi(b)
-
-	switch operator {
-	case LESS:
-		return intValue < intCompareValue
-	case LESS_OR_EQUAL:
-		return intValue <= intCompareValue
-	case EQUAL:
-		return intValue == intCompareValue
-	case GREATER:
-		return intValue > intCompareValue
-	case GREATER_OR_EQUAL:
-		return intValue >= intCompareValue
-	}
-
-	return false
-}
-
-func (operator EqualityOperator) compareString(a string, b string) bool {
-	if operator == EQUAL {
-		return a == b
-	}
-
-	return false
-}
+package sql
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+)
+
+type EqualityOperator int
+
+const (
+	LESS             EqualityOperator = 1               // Less than operator <
+	GREATER          EqualityOperator = 2               // Greater than operator >
+	EQUAL            EqualityOperator = 4               // Equals to operator =
+	LESS_OR_EQUAL    EqualityOperator = LESS | EQUAL    // Less than or equals to operator <=
+	GREATER_OR_EQUAL EqualityOperator = GREATER | EQUAL // Greater than or equals to operator >=
+	NOT_EQUAL        EqualityOperator = LESS | GREATER  // Not equals to operator <> or !=
+)
+
+// Get equality operator from string
+func GetEqualityOperator(s string) EqualityOperator {
+	switch s {
+	case "<":
+		return LESS
+	case ">":
+		return GREATER
+	case "=":
+		return EQUAL
+	case "<=":
+		return LESS_OR_EQUAL
+	case ">=":
+		return GREATER_OR_EQUAL
+	case "<>", "!=":
+		return NOT_EQUAL
+	}
+
+	return -1
+}
+
+// Get a string value of an equality operator
+func (operator EqualityOperator) ToString() string {
+	switch operator {
+	case LESS:
+		return "<"
+	case GREATER:
+		return ">"
+	case EQUAL:
+		return "="
+	case LESS_OR_EQUAL:
+		return "<="
+	case GREATER_OR_EQUAL:
+		return ">="
+	case NOT_EQUAL:
+		return "<>"
+	}
+
+	return ""
+}
+
+// Inverse of the equality operator, less than changes to greater than etc
+func (operator EqualityOperator) Inverse() EqualityOperator {
+	switch operator {
+	case LESS:
+		return GREATER
+	case LESS_OR_EQUAL:
+		return GREATER_OR_EQUAL
+	case EQUAL:
+		return EQUAL
+	case GREATER:
+		return LESS
+	case GREATER_OR_EQUAL:
+		return LESS_OR_EQUAL
+	case NOT_EQUAL:
+		return NOT_EQUAL
+	}
+
+	return -1
+}
+
+// Compare values, based of type.
+// Comparison with a null value is always unknown
+func (operator EqualityOperator) Compare(t ColumnType, a Value, b Value) Truth {
+	if a.IsNull() || b.IsNull() {
+		return TRUTH_UNKNOWN
+	}
+
+	switch t {
+	case TYPE_INT:
+		return GetTruth(operator.compareInt(a.String, b.String))
+	case TYPE_VARCHAR:
+		return GetTruth(operator.compareString(a.String, b.String))
+	case TYPE_FLOAT:
+		return GetTruth(operator.compareFloat(a.String, b.String))
+	}
+
+	return TRUTH_FALSE
+}
+
+// Compare values for ordering, based of type.
+// Null values are equal to each other and smaller than any other value
+func Compare(t ColumnType, a Value, b Value) int {
+	if a.IsNull() || b.IsNull() {
+		return cmp.Compare(GetTruth(a.Valid), GetTruth(b.Valid))
+	}
+
+	return compareStrings(t, a.String, b.String)
+}
+
+func compareStrings(t ColumnType, a string, b string) int {
+	switch t {
+	case TYPE_INT:
+		inta, errA := strconv.Atoi(a)
+		intb, errB := strconv.Atoi(b)
+		if errA != nil || errB != nil {
+			return compareStrings(TYPE_FLOAT, a, b) // DECIMAL NUMBERS ARE COMPARED TO INTEGERS BY VALUE
+		}
+
+		return cmp.Compare(inta, intb)
+	case TYPE_VARCHAR:
+		return strings.Compare(a, b)
+	case TYPE_FLOAT:
+		floata, _ := strconv.ParseFloat(a, 64)
+		floatb, _ := strconv.ParseFloat(b, 64)
+		return cmp.Compare(floata, floatb)
+	}
+
+	return 0
+}
+
+func (operator EqualityOperator) compareInt(a string, b string) bool {
+	intValue, err := strconv.Atoi(a)
+	intCompareValue, compareErr := strconv.Atoi(b)
+	if err != nil || compareErr != nil {
+		return operator.compareFloat(a, b) // DECIMAL NUMBERS ARE COMPARED TO INTEGERS BY VALUE
+	}
+
+	switch operator {
+	case LESS:
+		return intValue < intCompareValue
+	case LESS_OR_EQUAL:
+		return intValue <= intCompareValue
+	case EQUAL:
+		return intValue == intCompareValue
+	case GREATER:
+		return intValue > intCompareValue
+	case GREATER_OR_EQUAL:
+		return intValue >= intCompareValue
+	case NOT_EQUAL:
+		return intValue != intCompareValue
+	}
+
+	return false
+}
+
+func (operator EqualityOperator) compareFloat(a string, b string) bool {
+	floatValue, _ := strconv.ParseFloat(a, 64)
+	floatCompareValue, _ := strconv.ParseFloat(b, 64)
+
+	switch operator {
+	case LESS:
+		return floatValue < floatCompareValue
+	case LESS_OR_EQUAL:
+		return floatValue <= floatCompareValue
+	case EQUAL:
+		return floatValue == floatCompareValue
+	case GREATER:
+		return floatValue > floatCompareValue
+	case GREATER_OR_EQUAL:
+		return floatValue >= floatCompareValue
+	case NOT_EQUAL:
+		return floatValue != floatCompareValue
+	}
+
+	return false
+}
+
+func (operator EqualityOperator) compareString(a string, b string) bool {
+	switch operator {
+	case LESS:
+		return a < b
+	case LESS_OR_EQUAL:
+		return a <= b
+	case EQUAL:
+		return a == b
+	case GREATER:
+		return a > b
+	case GREATER_OR_EQUAL:
+		return a >= b
+	case NOT_EQUAL:
+		return a != b
+	}
+
+	return false
+}
@@ -0,0 +1,614 @@
+package sql
+
+import (
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Compile a syntax tree of a statement to an operation.
+// Tokens are the tokens the statement was parsed from, used for the location of the errors
+func compile(tokens []*Token, statement Statement) (Operation, error) {
+	compiler := &compiler{tokens: tokens}
+	return compiler.compileStatement(statement)
+}
+
+// Compiler of syntax trees, checks what the parser cannot such as types and where columns are required
+type compiler struct {
+	tokens []*Token
+}
+
+// Create a syntax error at a token
+func (compiler *compiler) errorf(position int, format string, args ...any) *Error {
+	return newSyntaxError(compiler.tokens, position, format, args...)
+}
+
+// Compile a statement to an operation by the type of the statement
+func (compiler *compiler) compileStatement(statement Statement) (Operation, error) {
+	switch statement := statement.(type) {
+	case *SelectStatement:
+		return compiler.compileSelect(statement)
+	case *InsertStatement:
+		return compiler.compileInsert(statement)
+	case *UpdateStatement:
+		return compiler.compileUpdate(statement)
+	case *DeleteStatement:
+		condition, err := compiler.compileRowCondition(statement.Where, "where")
+		if err != nil {
+			return nil, err
+		}
+
+		return &DeleteOperation{TableName: statement.TableName, Condition: condition}, nil
+	case *CreateTableStatement:
+		return compiler.compileCreateTable(statement)
+	case *CreateIndexStatement:
+		return &CreateIndexOperation{
+			IndexName:   statement.IndexName,
+			TableName:   statement.TableName,
+			ColumnNames: statement.ColumnNames,
+			Unique:      statement.Unique,
+		}, nil
+	case *DropTableStatement:
+		return &DropOperation{TableName: statement.TableName}, nil
+	case *DropIndexStatement:
+		return &DropIndexOperation{IndexName: statement.IndexName}, nil
+	case *ExplainStatement:
+		operation, err := compiler.compileSelect(statement.Select)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ExplainOperation{Select: operation}, nil
+	case *TransactionControlStatement:
+		return &TransactionOperation{Statement: statement.Statement}, nil
+	}
+
+	return nil, compiler.errorf(statement.GetPosition(), "parser: no operation could be created, invalid operation")
+}
+
+// Compile a select statement, limit and offset must be non-negative whole numbers
+func (compiler *compiler) compileSelect(statement *SelectStatement) (*SelectOperation, error) {
+	operation := &SelectOperation{
+		TableName:   statement.From.Name,
+		Alias:       statement.From.Alias,
+		Joins:       []*Join{},
+		Projections: []*Projection{},
+		GroupBy:     []*Projection{},
+		Sorters:     []*Sorter{},
+	}
+
+	for _, expression := range statement.Projections {
+		projection, err := compiler.compileProjection(expression)
+		if err != nil {
+			return nil, err
+		}
+
+		operation.Projections = append(operation.Projections, projection)
+	}
+
+	for _, join := range statement.Joins {
+		condition, err := compiler.compileRowCondition(join.Condition, "on")
+		if err != nil {
+			return nil, err
+		}
+
+		operation.Joins = append(operation.Joins, &Join{
+			Type:      join.Type,
+			Table:     TableReference{TableName: join.Table.Name, Alias: join.Table.Alias},
+			Condition: condition,
+		})
+	}
+
+	var err error
+	operation.Condition, err = compiler.compileRowCondition(statement.Where, "where")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, expression := range statement.GroupBy {
+		column, ok := expression.(*ColumnExpr)
+		if !ok || column.Name == "*" {
+			return nil, compiler.errorf(expression.GetPosition(), "parser: group could not be created, expected a column name")
+		}
+
+		operation.GroupBy = append(operation.GroupBy, &Projection{ColumnName: column.Name, Function: AGGREGATE_NONE, Token: compiler.token(expression)})
+	}
+
+	operation.Having, err = compiler.compileOptionalCondition(statement.Having)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, order := range statement.OrderBy {
+		projection, err := compiler.compileProjection(order.Expression)
+		if err != nil {
+			return nil, err
+		}
+
+		operation.Sorters = append(operation.Sorters, &Sorter{
+			ColumnName: projection.ColumnName,
+			Function:   projection.Function,
+			Direction:  order.Direction,
+			Token:      compiler.token(order.Expression),
+		})
+	}
+
+	if statement.Limit == nil && statement.Offset == nil {
+		return operation, nil
+	}
+
+	operation.Limiter = &Limiter{Limit: -1, Offset: 0}
+	if statement.Limit != nil {
+		operation.Limiter.Limit, err = compiler.compileRowCount(statement.Limit, "limit")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if statement.Offset != nil {
+		operation.Limiter.Offset, err = compiler.compileRowCount(statement.Offset, "offset")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return operation, nil
+}
+
+// Compile a number of rows of a limit or an offset clause
+func (compiler *compiler) compileRowCount(expression Expr, context string) (int, error) {
+	value, err := compiler.compileValue(expression)
+	if err != nil {
+		return -1, err
+	}
+
+	count, err := strconv.Atoi(value.String)
+	if err != nil || count < 0 {
+		return -1, compiler.errorf(expression.GetPosition(), "parser: %s could not be created, invalid number of rows: %s", context, value.ToString())
+	}
+
+	return count, nil
+}
+
+// Compile an insert statement, every row must have a value for each of the listed columns and a column can be listed only once
+func (compiler *compiler) compileInsert(statement *InsertStatement) (Operation, error) {
+	operation := &InsertOperation{TableName: statement.TableName, ColumnNames: []string{}, Rows: [][]Value{}, Tokens: [][]*Token{}}
+	for i, column := range statement.Columns {
+		if slices.ContainsFunc(statement.Columns[:i], func(c *ColumnExpr) bool { return c.Name == column.Name }) {
+			return nil, compiler.errorf(column.Position, "parser: insert operation could not be created, column %s is listed more than once", column.Name)
+		}
+
+		operation.ColumnNames = append(operation.ColumnNames, column.Name)
+	}
+
+	if statement.Select != nil {
+		selectOperation, err := compiler.compileSelect(statement.Select)
+		if err != nil {
+			return nil, err
+		}
+
+		operation.Select = selectOperation
+		return operation, nil
+	}
+
+	for _, row := range statement.Rows {
+		if len(operation.ColumnNames) > 0 && len(row) != len(operation.ColumnNames) {
+			return nil, compiler.errorf(row[0].GetPosition(), "parser: insert operation could not be created, %d columns but %d values", len(operation.ColumnNames), len(row))
+		}
+
+		values := []Value{}
+		for _, expression := range row {
+			value, err := compiler.compileValue(expression)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		operation.Rows = append(operation.Rows, values)
+		operation.Tokens = append(operation.Tokens, Map(row, compiler.token))
+	}
+
+	return operation, nil
+}
+
+// Compile an update statement, assigned values can be expressions of the columns of the row and a column can be assigned only once
+func (compiler *compiler) compileUpdate(statement *UpdateStatement) (Operation, error) {
+	operation := &UpdateOperation{TableName: statement.TableName, Assignments: []*Assignment{}}
+	for i, assignment := range statement.Assignments {
+		if slices.ContainsFunc(statement.Assignments[:i], func(a *AssignmentClause) bool { return a.ColumnName == assignment.ColumnName }) {
+			return nil, compiler.errorf(assignment.Position, "parser: update operation could not be created, column %s is assigned more than once", assignment.ColumnName)
+		}
+
+		expression, err := compiler.compileExpression(assignment.Expression)
+		if err != nil {
+			return nil, err
+		}
+
+		operation.Assignments = append(operation.Assignments, &Assignment{
+			ColumnName: assignment.ColumnName,
+			Expression: expression,
+			Token:      compiler.tokenAt(assignment.Position),
+		})
+	}
+
+	var err error
+	operation.Condition, err = compiler.compileRowCondition(statement.Where, "where")
+	if err != nil {
+		return nil, err
+	}
+
+	return operation, nil
+}
+
+// Compile a create table statement, types of the columns are checked
+func (compiler *compiler) compileCreateTable(statement *CreateTableStatement) (Operation, error) {
+	data := []ColData{}
+	for _, column := range statement.Columns {
+		colType, err := GetType(column.TypeName)
+		if err != nil {
+			return nil, compiler.errorf(column.Position+1, "parser: create operation could not be created, %s", err.Error())
+		}
+
+		colData := ColData{ColName: column.Name, ColType: colType, PrimaryKey: column.PrimaryKey, NotNull: column.NotNull, Unique: column.Unique}
+		if column.Default != nil {
+			colData.Default, err = compiler.compileValue(column.Default)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		data = append(data, colData)
+	}
+
+	return &CreateOperation{TableName: statement.TableName, Data: data}, nil
+}
+
+// Compile a column or an aggregate function of a single column such as COUNT(*)
+func (compiler *compiler) compileProjection(expression Expr) (*Projection, error) {
+	switch expression := expression.(type) {
+	case *ColumnExpr:
+		return &Projection{ColumnName: expression.Name, Function: AGGREGATE_NONE, Token: compiler.token(expression)}, nil
+	case *FunctionExpr:
+		function, err := GetAggregateFunction(expression.Name)
+		if err != nil {
+			return nil, compiler.errorf(expression.Position, "parser: %s", err.Error())
+		}
+
+		column, ok := expression.Argument.(*ColumnExpr)
+		if !ok {
+			return nil, compiler.errorf(expression.Argument.GetPosition(), "parser: aggregate function could not be read, invalid arguments for %s", function.ToString())
+		}
+
+		return &Projection{ColumnName: column.Name, Function: function, Token: compiler.token(expression)}, nil
+	}
+
+	return nil, compiler.errorf(expression.GetPosition(), "parser: column could not be read, expected a column name but got '%s'", compiler.tokenValue(expression.GetPosition()))
+}
+
+// Compile a condition that can be missing, nil is compiled to nil
+func (compiler *compiler) compileOptionalCondition(expression Expr) (Condition, error) {
+	if expression == nil {
+		return nil, nil
+	}
+
+	return compiler.compileCondition(expression)
+}
+
+// Compile a condition that is evaluated for each row such as where or on, nil is compiled to nil.
+// Aggregate functions can only be used in the select list, having and order by, clause is the name of the clause used in the error
+func (compiler *compiler) compileRowCondition(expression Expr, clause string) (Condition, error) {
+	condition, err := compiler.compileOptionalCondition(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	var aggregate *Projection
+	walkProjections(condition, func(projection *Projection) {
+		if aggregate == nil && projection.IsAggregate() {
+			aggregate = projection
+		}
+	})
+
+	if aggregate == nil {
+		return condition, nil
+	}
+
+	// THE TOKEN IS ALWAYS ONE OF THE TOKENS OF THE STATEMENT, THE FIRST TOKEN IS USED IF IT IS MISSING
+	position := max(slices.Index(compiler.tokens, aggregate.Token), 0)
+	return nil, compiler.errorf(position, "parser: condition could not be created, aggregate function %s cannot be used in %s", aggregate.Name(), clause)
+}
+
+// Compile a condition of comparisons combined with logical operators
+func (compiler *compiler) compileCondition(expression Expr) (Condition, error) {
+	switch expression := expression.(type) {
+	case *LogicalExpr:
+		left, err := compiler.compileCondition(expression.Left)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := compiler.compileCondition(expression.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LogicalCondition{Operator: expression.Operator, Left: left, Right: right}, nil
+	case *NotExpr:
+		condition, err := compiler.compileCondition(expression.Operand)
+		if err != nil {
+			return nil, err
+		}
+
+		return &NotCondition{Condition: condition}, nil
+	case *IsNullExpr:
+		projection, err := compiler.compileProjection(expression.Operand)
+		if err != nil {
+			return nil, compiler.errorf(expression.Operand.GetPosition(), "parser: condition could not be created, is null can only be used with a column")
+		}
+
+		return &NullFilter{ColumnName: projection.ColumnName, Function: projection.Function, IsNot: expression.IsNot, Token: compiler.token(expression.Operand)}, nil
+	case *InExpr:
+		return compiler.compileIn(expression)
+	case *BetweenExpr:
+		return compiler.compileBetween(expression)
+	case *LikeExpr:
+		return compiler.compileLike(expression)
+	case *ComparisonExpr:
+		left, ok := expression.Left.(*ComparisonExpr)
+		if !ok {
+			return compiler.compileComparison(expression.Position, expression.Left, expression.Operator, expression.Right)
+		}
+
+		// CHAINED COMPARISON 0 < x < 1 IS 0 < x AND x < 1
+		condition, err := compiler.compileCondition(left)
+		if err != nil {
+			return nil, err
+		}
+
+		comparison, err := compiler.compileComparison(expression.Position, left.Right, expression.Operator, expression.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LogicalCondition{Operator: LOGICAL_AND, Left: condition, Right: comparison}, nil
+	}
+
+	return nil, compiler.errorf(expression.GetPosition(), "parser: condition could not be created, expected a comparison but got '%s'", compiler.tokenValue(expression.GetPosition()))
+}
+
+// Compile a comparison of a column to a value or of two columns, a value on the left side is moved to the right side.
+// Position is the index of the comparison operator
+func (compiler *compiler) compileComparison(position int, left Expr, operator EqualityOperator, right Expr) (Condition, error) {
+	if operator == -1 {
+		return nil, compiler.errorf(position, "parser: condition could not be created, invalid comparison operator")
+	}
+
+	leftProjection, leftErr := compiler.compileProjection(left)
+	rightProjection, rightErr := compiler.compileProjection(right)
+	switch {
+	case leftErr != nil && rightErr != nil:
+		return nil, compiler.errorf(left.GetPosition(), "parser: condition could not be created, comparison must have a column")
+	case rightErr != nil:
+		value, err := compiler.compileValue(right)
+		if err != nil {
+			return nil, err
+		}
+
+		return newFilter(leftProjection, compiler.token(left), operator, value), nil
+	case leftErr != nil:
+		value, err := compiler.compileValue(left)
+		if err != nil {
+			return nil, err
+		}
+
+		return newFilter(rightProjection, compiler.token(right), operator.Inverse(), value), nil
+	case leftProjection.IsAggregate() || rightProjection.IsAggregate():
+		return nil, compiler.errorf(left.GetPosition(), "parser: condition could not be created, aggregate functions can only be compared to values")
+	}
+
+	return &ColumnComparison{
+		LeftColumn:  leftProjection.ColumnName,
+		Operator:    operator,
+		RightColumn: rightProjection.ColumnName,
+		LeftToken:   compiler.token(left),
+		RightToken:  compiler.token(right),
+	}, nil
+}
+
+// Compile an in predicate of a column and a list of values
+func (compiler *compiler) compileIn(expression *InExpr) (Condition, error) {
+	projection, err := compiler.compileProjection(expression.Operand)
+	if err != nil {
+		return nil, compiler.errorf(expression.Operand.GetPosition(), "parser: condition could not be created, in can only be used with a column")
+	}
+
+	filter := &InFilter{ColumnName: projection.ColumnName, Function: projection.Function, Values: []Value{}, Token: compiler.token(expression.Operand)}
+	for _, valueExpression := range expression.Values {
+		value, err := compiler.compileValue(valueExpression)
+		if err != nil {
+			return nil, err
+		}
+
+		filter.Values = append(filter.Values, value)
+	}
+
+	return negateIf(filter, expression.IsNot), nil
+}
+
+// Compile a between predicate to a pair of comparisons combined with and, so that an index can be used for the range
+func (compiler *compiler) compileBetween(expression *BetweenExpr) (Condition, error) {
+	projection, err := compiler.compileProjection(expression.Operand)
+	if err != nil {
+		return nil, compiler.errorf(expression.Operand.GetPosition(), "parser: condition could not be created, between can only be used with a column")
+	}
+
+	lower, err := compiler.compileValue(expression.Lower)
+	if err != nil {
+		return nil, err
+	}
+
+	upper, err := compiler.compileValue(expression.Upper)
+	if err != nil {
+		return nil, err
+	}
+
+	token := compiler.token(expression.Operand)
+	condition := And(newFilter(projection, token, GREATER_OR_EQUAL, lower), newFilter(projection, token, LESS_OR_EQUAL, upper))
+	return negateIf(condition, expression.IsNot), nil
+}
+
+// Compile a like predicate, the pattern and the escape character must be text values.
+// The escape character must be a single character and it cannot be the last character of the pattern
+func (compiler *compiler) compileLike(expression *LikeExpr) (Condition, error) {
+	projection, err := compiler.compileProjection(expression.Operand)
+	if err != nil {
+		return nil, compiler.errorf(expression.Operand.GetPosition(), "parser: condition could not be created, like can only be used with a column")
+	}
+
+	pattern, err := compiler.compileString(expression.Pattern, "pattern")
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &LikeFilter{ColumnName: projection.ColumnName, Function: projection.Function, Pattern: pattern, Token: compiler.token(expression.Operand)}
+	if expression.Escape == nil {
+		return negateIf(filter, expression.IsNot), nil
+	}
+
+	escape, err := compiler.compileString(expression.Escape, "escape character")
+	if err != nil {
+		return nil, err
+	}
+
+	if utf8.RuneCountInString(escape) != 1 {
+		return nil, compiler.errorf(expression.Escape.GetPosition(), "parser: condition could not be created, escape must be a single character but got '%s'", escape)
+	}
+
+	filter.Escape, _ = utf8.DecodeRuneInString(escape)
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != filter.Escape {
+			continue
+		}
+
+		if i == len(runes)-1 {
+			return nil, compiler.errorf(expression.Pattern.GetPosition(), "parser: condition could not be created, pattern cannot end with the escape character")
+		}
+
+		i++ // ESCAPED CHARACTER IS SKIPPED
+	}
+
+	return negateIf(filter, expression.IsNot), nil
+}
+
+// Compile a text value in single quotes, context is the name of the value used in the error
+func (compiler *compiler) compileString(expression Expr, context string) (string, error) {
+	literal, ok := expression.(*LiteralExpr)
+	if !ok || literal.Kind != LITERAL_STRING {
+		return "", compiler.errorf(expression.GetPosition(), "parser: condition could not be created, %s must be a text value but got '%s'", context, compiler.tokenValue(expression.GetPosition()))
+	}
+
+	return literal.Value.String, nil
+}
+
+// Compile a value expression of columns, literals and arithmetic
+func (compiler *compiler) compileExpression(expression Expr) (Expression, error) {
+	switch expression := expression.(type) {
+	case *ColumnExpr:
+		if expression.Name == "*" {
+			break
+		}
+
+		return &IdentifierExpression{Name: expression.Name}, nil
+	case *LiteralExpr:
+		return &LiteralExpression{Value: expression.Value, Quoted: expression.Kind == LITERAL_STRING}, nil
+	case *UnaryExpr:
+		operand, err := compiler.compileExpression(expression.Operand)
+		if err != nil {
+			return nil, err
+		}
+
+		return &NegateExpression{Expression: operand}, nil
+	case *BinaryExpr:
+		left, err := compiler.compileExpression(expression.Left)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := compiler.compileExpression(expression.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ArithmeticExpression{Operator: expression.Operator, Left: left, Right: right}, nil
+	}
+
+	return nil, compiler.errorf(expression.GetPosition(), "parser: expression could not be created, unexpected token '%s'", compiler.tokenValue(expression.GetPosition()))
+}
+
+// Compile a single value, a literal or a negated number
+func (compiler *compiler) compileValue(expression Expr) (Value, error) {
+	switch expression := expression.(type) {
+	case *LiteralExpr:
+		return expression.Value, nil
+	case *UnaryExpr:
+		literal, ok := expression.Operand.(*LiteralExpr)
+		if !ok || literal.Kind != LITERAL_NUMBER {
+			break
+		}
+
+		value, _, err := (&NegateExpression{Expression: &LiteralExpression{Value: literal.Value}}).Evaluate(nil, 0)
+		if err != nil {
+			return Value{}, compiler.errorf(expression.Position, "parser: value could not be read, %s", GetError(err).Message)
+		}
+
+		return value, nil
+	}
+
+	return Value{}, compiler.errorf(expression.GetPosition(), "parser: value could not be read, expected a value but got '%s'", compiler.tokenValue(expression.GetPosition()))
+}
+
+// Get the token an expression starts at, nil if there is no token at the position of the expression
+func (compiler *compiler) token(expression Expr) *Token {
+	return compiler.tokenAt(expression.GetPosition())
+}
+
+// Get the token at an index, nil if there is no token at the index
+func (compiler *compiler) tokenAt(index int) *Token {
+	if index < 0 || index >= len(compiler.tokens) {
+		return nil
+	}
+
+	return compiler.tokens[index]
+}
+
+// Get the value of a token, empty string if there is no token at the index
+func (compiler *compiler) tokenValue(index int) string {
+	if index < 0 || index >= len(compiler.tokens) {
+		return ""
+	}
+
+	return compiler.tokens[index].Value
+}
+
+// Negate a condition if isNot is true, used for the not forms of predicates
+func negateIf(condition Condition, isNot bool) Condition {
+	if !isNot {
+		return condition
+	}
+
+	return &NotCondition{Condition: condition}
+}
+
+// Create a filter comparing a column or an aggregate function to a value
+func newFilter(projection *Projection, token *Token, operator EqualityOperator, value Value) *Filter {
+	return &Filter{
+		ColumnName:   projection.ColumnName,
+		Function:     projection.Function,
+		Operator:     operator,
+		CompareValue: value,
+		Token:        token,
+	}
+}
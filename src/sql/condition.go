@@ -0,0 +1,267 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Base contract of a where condition, a single node in a boolean expression tree
+type Condition interface {
+	Evaluate(table *Table, rowIndex int) Truth
+}
+
+// Enum to represent a logical operator between two conditions, values are prefixed with LOGICAL
+type LogicalOperator int
+
+const (
+	LOGICAL_AND LogicalOperator = iota // Both conditions must be true
+	LOGICAL_OR                         // Either one of the conditions must be true
+)
+
+// Get a logical operator from a string (not casesensitive)
+func GetLogicalOperator(s string) (LogicalOperator, error) {
+	switch strings.ToUpper(s) {
+	case "AND":
+		return LOGICAL_AND, nil
+	case "OR":
+		return LOGICAL_OR, nil
+	}
+
+	return -1, fmt.Errorf("invalid logical operator: %s", s)
+}
+
+// Get a string value of a logical operator
+func (operator LogicalOperator) ToString() string {
+	switch operator {
+	case LOGICAL_AND:
+		return "AND"
+	case LOGICAL_OR:
+		return "OR"
+	}
+
+	return ""
+}
+
+// Represents two conditions combined with a logical operator
+type LogicalCondition struct {
+	Operator LogicalOperator
+	Left     Condition
+	Right    Condition
+}
+
+// Evaluate the combined condition for a row
+func (condition *LogicalCondition) Evaluate(table *Table, rowIndex int) Truth {
+	left := condition.Left.Evaluate(table, rowIndex)
+	switch condition.Operator {
+	case LOGICAL_AND:
+		if left == TRUTH_FALSE {
+			return TRUTH_FALSE
+		}
+
+		return left.And(condition.Right.Evaluate(table, rowIndex))
+	case LOGICAL_OR:
+		if left == TRUTH_TRUE {
+			return TRUTH_TRUE
+		}
+
+		return left.Or(condition.Right.Evaluate(table, rowIndex))
+	}
+
+	return TRUTH_FALSE
+}
+
+// Represents a negated condition
+type NotCondition struct {
+	Condition Condition
+}
+
+// Evaluate the negation of the inner condition for a row
+func (condition *NotCondition) Evaluate(table *Table, rowIndex int) Truth {
+	return condition.Condition.Evaluate(table, rowIndex).Not()
+}
+
+// Combine two conditions with and, nil conditions are ignored
+func And(left Condition, right Condition) Condition {
+	if left == nil {
+		return right
+	}
+
+	if right == nil {
+		return left
+	}
+
+	return &LogicalCondition{Operator: LOGICAL_AND, Left: left, Right: right}
+}
+
+// Call visit for every column or aggregate function compared in a condition tree
+func walkProjections(condition Condition, visit func(projection *Projection)) {
+	switch c := condition.(type) {
+	case *LogicalCondition:
+		walkProjections(c.Left, visit)
+		walkProjections(c.Right, visit)
+	case *NotCondition:
+		walkProjections(c.Condition, visit)
+	case *Filter:
+		visit(c.projection())
+	case *NullFilter:
+		visit(c.projection())
+	case *InFilter:
+		visit(c.projection())
+	case *LikeFilter:
+		visit(c.projection())
+	}
+}
+
+// Bind a condition to the columns of a table, compared values are converted to the types of the compared columns.
+// Returns a new condition, the condition itself is not changed. Unknown columns and values of invalid types are errors located at the token of the column
+func bindCondition(table *Table, condition Condition) (Condition, error) {
+	switch c := condition.(type) {
+	case *LogicalCondition:
+		left, err := bindCondition(table, c.Left)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := bindCondition(table, c.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LogicalCondition{Operator: c.Operator, Left: left, Right: right}, nil
+	case *NotCondition:
+		inner, err := bindCondition(table, c.Condition)
+		if err != nil {
+			return nil, err
+		}
+
+		return &NotCondition{Condition: inner}, nil
+	case *Filter:
+		col, err := table.getColumnByName(c.projection().Name())
+		if err != nil {
+			return nil, locateError(err, c.Token)
+		}
+
+		filter := *c
+		filter.CompareValue, err = bindValue(col, c.CompareValue)
+		if err != nil {
+			return nil, locateError(err, c.Token)
+		}
+
+		return &filter, nil
+	case *InFilter:
+		col, err := table.getColumnByName(c.projection().Name())
+		if err != nil {
+			return nil, locateError(err, c.Token)
+		}
+
+		filter := *c
+		filter.Values = make([]Value, len(c.Values))
+		for i, value := range c.Values {
+			filter.Values[i], err = bindValue(col, value)
+			if err != nil {
+				return nil, locateError(err, c.Token)
+			}
+		}
+
+		return &filter, nil
+	case *NullFilter:
+		if _, err := table.getColumnByName(c.projection().Name()); err != nil {
+			return nil, locateError(err, c.Token)
+		}
+	case *LikeFilter:
+		if _, err := table.getColumnByName(c.projection().Name()); err != nil {
+			return nil, locateError(err, c.Token)
+		}
+	case *ColumnComparison:
+		left, err := table.getColumnByName(c.LeftColumn)
+		if err != nil {
+			return nil, locateError(err, c.LeftToken)
+		}
+
+		right, err := table.getColumnByName(c.RightColumn)
+		if err != nil {
+			return nil, locateError(err, c.RightToken)
+		}
+
+		if _, err := getComparisonType(left, right); err != nil {
+			return nil, locateError(err, c.LeftToken)
+		}
+	}
+
+	return condition, nil
+}
+
+// Convert a value compared to a column to the type of the column.
+// Decimal numbers compared to an integer column are kept as they are, those are compared by their value
+func bindValue(col *Column, value Value) (Value, error) {
+	t := col.Type
+	if t == TYPE_INT {
+		if _, err := TYPE_INT.Coerce(value); err != nil {
+			t = TYPE_FLOAT
+		}
+	}
+
+	coerced, err := t.Coerce(value)
+	if err != nil {
+		return Value{}, newError(ERROR_TYPE, "'%s' cannot be compared to column %s of type %s", value.String, col.Name, col.Type.ToString())
+	}
+
+	return coerced, nil
+}
+
+// Enum to represent a result of a condition in three-valued logic, values are prefixed with TRUTH.
+// Comparisons with null values are unknown
+type Truth int
+
+const (
+	TRUTH_FALSE   Truth = iota // Condition is false
+	TRUTH_TRUE                 // Condition is true
+	TRUTH_UNKNOWN              // Condition cannot be determined, a null value was compared
+)
+
+// Get a truth value from a bool
+func GetTruth(b bool) Truth {
+	if b {
+		return TRUTH_TRUE
+	}
+
+	return TRUTH_FALSE
+}
+
+// Negation of the truth value, unknown stays unknown
+func (truth Truth) Not() Truth {
+	switch truth {
+	case TRUTH_FALSE:
+		return TRUTH_TRUE
+	case TRUTH_TRUE:
+		return TRUTH_FALSE
+	}
+
+	return TRUTH_UNKNOWN
+}
+
+// Conjunction of truth values, false if either is false
+func (truth Truth) And(other Truth) Truth {
+	if truth == TRUTH_FALSE || other == TRUTH_FALSE {
+		return TRUTH_FALSE
+	}
+
+	if truth == TRUTH_UNKNOWN || other == TRUTH_UNKNOWN {
+		return TRUTH_UNKNOWN
+	}
+
+	return TRUTH_TRUE
+}
+
+// Disjunction of truth values, true if either is true
+func (truth Truth) Or(other Truth) Truth {
+	if truth == TRUTH_TRUE || other == TRUTH_TRUE {
+		return TRUTH_TRUE
+	}
+
+	if truth == TRUTH_UNKNOWN || other == TRUTH_UNKNOWN {
+		return TRUTH_UNKNOWN
+	}
+
+	return TRUTH_FALSE
+}
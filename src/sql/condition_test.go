@@ -0,0 +1,269 @@
+package sql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConditionPrecedence(t *testing.T) {
+	table := &Table{Name: "t", Columns: []*Column{
+		{Name: "id", Type: TYPE_INT, Values: NewValues("1", "2", "3")},
+		{Name: "age", Type: TYPE_INT, Values: NewValues("50", "25", "45")},
+	}}
+
+	operation, err := Parse(Tokenize([]byte("SELECT id FROM t WHERE (age > 40 OR id = 2) AND NOT id = 3")))
+	if err != nil {
+		t.Fatalf("parse returned an error but should not have: %s", err.Error())
+	}
+
+	data, err := operation.(*SelectOperation).getData(NewDatabase("", table))
+	if err != nil || len(data.Data) != 2 || data.Data[0][0].String != "1" || data.Data[1][0].String != "2" {
+		t.Fatalf("wrong rows included, expected ids 1 and 2, got %v", data.Data)
+	}
+}
+
+func TestConditionMissingParenthesis(t *testing.T) {
+	_, err := Parse(Tokenize([]byte("SELECT * FROM t WHERE (age > 40")))
+	if err == nil {
+		t.Fatal("error was not thrown but should have")
+	}
+}
+
+func TestConditionNull(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "age", Type: TYPE_INT, Values: []Value{NewValue("50"), {}}}}}
+	filter := &Filter{ColumnName: "age", Operator: GREATER, CompareValue: NewValue("40")}
+
+	if (&NotCondition{Condition: filter}).Evaluate(table, 1) != TRUTH_UNKNOWN {
+		t.Fatal("negated comparison with null should be unknown")
+	}
+
+	if (&LogicalCondition{Operator: LOGICAL_OR, Left: filter, Right: &NullFilter{ColumnName: "age"}}).Evaluate(table, 1) != TRUTH_TRUE {
+		t.Fatal("unknown or true should be true")
+	}
+
+	if (&NullFilter{ColumnName: "age", IsNot: true}).Evaluate(table, 0) != TRUTH_TRUE {
+		t.Fatal("is not null should be true for a value")
+	}
+}
+
+func TestConditionNotEqual(t *testing.T) {
+	table := &Table{Name: "t", Columns: []*Column{
+		{Name: "id", Type: TYPE_INT, Values: NewValues("1", "2", "3")},
+		{Name: "name", Type: TYPE_VARCHAR, Values: NewValues("a", "b", "c")},
+	}}
+
+	operation, err := Parse(Tokenize([]byte("SELECT id FROM t WHERE id <> 1 AND name != 'c' -- comment")))
+	if err != nil {
+		t.Fatalf("parse returned an error but should not have: %s", err.Error())
+	}
+
+	data, err := operation.(*SelectOperation).getData(NewDatabase("", table))
+	if err != nil || len(data.Data) != 1 || data.Data[0][0].String != "2" {
+		t.Fatalf("wrong rows included, expected id 2, got %v", data.Data)
+	}
+}
+
+func TestConditionColumnComparison(t *testing.T) {
+	table := &Table{Name: "t", Columns: []*Column{
+		{Name: "id", Type: TYPE_INT, Values: NewValues("1", "2", "3")},
+		{Name: "name", Type: TYPE_VARCHAR, Values: NewValues("a", "b", "age")},
+		{Name: "surname", Type: TYPE_VARCHAR, Values: NewValues("a", "c", "d")},
+		{Name: "age", Type: TYPE_INT, Values: NewValues("50", "1", "45")},
+		{Name: "score", Type: TYPE_FLOAT, Values: NewValues("50", "0.5", "45.5")},
+	}}
+
+	tests := []struct {
+		condition string
+		ids       []string
+	}{
+		{"name = surname", []string{"1"}},
+		{"40 < age", []string{"1", "3"}},
+		{"age <= id", []string{"2"}},
+		{"id >= age", []string{"2"}},
+		{"age = score", []string{"1"}},
+		{"score = age", []string{"1"}},
+		{"score > age", []string{"3"}},
+		{"age < score", []string{"3"}},
+		{"name = 'age'", []string{"3"}},
+		{"name <> surname AND 'b' = name", []string{"2"}},
+		{"0 < id < age", []string{"1", "3"}},
+	}
+
+	for _, test := range tests {
+		operation, err := Parse(Tokenize([]byte("SELECT id FROM t WHERE " + test.condition)))
+		if err != nil {
+			t.Fatalf("parse returned an error but should not have: %s", err.Error())
+		}
+
+		data, err := operation.(*SelectOperation).getData(NewDatabase("", table))
+		ids := Map(data.Data, func(row []Value) string { return row[0].String })
+		if err != nil || !reflect.DeepEqual(ids, test.ids) {
+			t.Fatalf("wrong rows included for %s, expected ids %v, got %v", test.condition, test.ids, ids)
+		}
+	}
+
+	for _, condition := range []string{"1 = 1", "age = SELECT", "age IS 1", "1 < 2 < age", "(age = 1", "age = id +"} {
+		if _, err := Parse(Tokenize([]byte("SELECT id FROM t WHERE " + condition))); err == nil {
+			t.Fatalf("parse should have returned an error for %s", condition)
+		}
+	}
+
+	for _, condition := range []string{"name = age", "age = name", "score <> surname"} {
+		operation, err := Parse(Tokenize([]byte("SELECT id FROM t WHERE " + condition)))
+		if err != nil {
+			t.Fatalf("parse returned an error for %s but should not have: %s", condition, err.Error())
+		}
+
+		_, err = operation.(*SelectOperation).getData(NewDatabase("", table))
+		if err == nil || GetError(err).Category != ERROR_TYPE {
+			t.Fatalf("get should have returned a type error for %s, got %v", condition, err)
+		}
+	}
+}
+
+func TestConditionPredicates(t *testing.T) {
+	table := &Table{Name: "t", Columns: []*Column{
+		{Name: "id", Type: TYPE_INT, Values: NewValues("1", "2", "3", "4", "5")},
+		{Name: "name", Type: TYPE_VARCHAR, Values: []Value{NewValue("apple"), NewValue("banana"), NewValue("50%"), NewValue("a_b"), {}}},
+		{Name: "age", Type: TYPE_INT, Values: []Value{NewValue("50"), NewValue("1"), NewValue("45"), {}, NewValue("20")}},
+	}}
+
+	tests := []struct {
+		condition string
+		ids       []string
+	}{
+		{"id IN (2, 4, 9)", []string{"2", "4"}},
+		{"id NOT IN (2, 4)", []string{"1", "3", "5"}},
+		{"id NOT IN (2, NULL)", []string{}},
+		{"name IN ('apple', 'a_b')", []string{"1", "4"}},
+		{"age BETWEEN 20 AND 45", []string{"3", "5"}},
+		{"age NOT BETWEEN 20 AND 45", []string{"1", "2"}},
+		{"name BETWEEN 'a' AND 'b'", []string{"1", "4"}},
+		{"name < 'b'", []string{"1", "3", "4"}},
+		{"name >= 'banana'", []string{"2"}},
+		{"name LIKE 'a%'", []string{"1", "4"}},
+		{"name LIKE '_____'", []string{"1"}},
+		{"name LIKE '%an%a'", []string{"2"}},
+		{"name NOT LIKE '%a%'", []string{"3"}},
+		{"name LIKE 'a\\_%' ESCAPE '\\'", []string{"4"}},
+		{"name LIKE '%!%' ESCAPE '!'", []string{"3"}},
+		{"NOT name LIKE 'a%' AND id IN (2, 3)", []string{"2", "3"}},
+	}
+
+	for _, test := range tests {
+		operation, err := Parse(Tokenize([]byte("SELECT id FROM t WHERE " + test.condition)))
+		if err != nil {
+			t.Fatalf("parse returned an error for %s but should not have: %s", test.condition, err.Error())
+		}
+
+		data, err := operation.(*SelectOperation).getData(NewDatabase("", table))
+		ids := Map(data.Data, func(row []Value) string { return row[0].String })
+		if err != nil || !reflect.DeepEqual(ids, test.ids) {
+			t.Fatalf("wrong rows included for %s, expected ids %v, got %v", test.condition, test.ids, ids)
+		}
+	}
+
+	for _, condition := range []string{"1 IN (1, 2)", "id IN ()", "id IN (age)", "age BETWEEN 1", "name LIKE 1", "name LIKE age", "name LIKE 'a' ESCAPE 'ab'", "name LIKE 'a!' ESCAPE '!'", "name NOT NULL"} {
+		if _, err := Parse(Tokenize([]byte("SELECT id FROM t WHERE " + condition))); err == nil {
+			t.Fatalf("parse should have returned an error for %s", condition)
+		}
+	}
+}
+
+func TestConditionValueType(t *testing.T) {
+	table := &Table{Name: "t", Columns: []*Column{
+		{Name: "id", Type: TYPE_INT, Values: NewValues("1", "2", "3")},
+		{Name: "age", Type: TYPE_INT, Values: NewValues("8", "10", "0")},
+	}}
+
+	indexed := table.clone()
+	if err := indexed.addIndex(&Index{Name: "index", ColumnNames: []string{"age"}}); err != nil {
+		t.Fatal("add index returned an error but should not have")
+	}
+
+	tests := []struct {
+		condition string
+		ids       []string
+	}{
+		{"age > 9.5", []string{"2"}},
+		{"age <= 8.0", []string{"1", "3"}},
+		{"age = 8.5", []string{}},
+		{"age = '10'", []string{"2"}},
+		{"age BETWEEN 0.5 AND 9.5", []string{"1"}},
+	}
+
+	for _, test := range tests {
+		operation, err := Parse(Tokenize([]byte("SELECT id FROM t WHERE " + test.condition)))
+		if err != nil {
+			t.Fatalf("parse returned an error for %s but should not have: %s", test.condition, err.Error())
+		}
+
+		for _, table := range []*Table{table, indexed} {
+			data, err := operation.(*SelectOperation).getData(NewDatabase("", table))
+			if err != nil {
+				t.Fatalf("get returned an error for %s but should not have: %s", test.condition, err.Error())
+			}
+
+			if ids := Map(data.Data, func(row []Value) string { return row[0].String }); !reflect.DeepEqual(ids, test.ids) {
+				t.Fatalf("wrong rows included for %s, expected ids %v, got %v", test.condition, test.ids, ids)
+			}
+		}
+	}
+
+	for _, condition := range []string{"age = 'zzz'", "age IN (1, 'a')"} {
+		operation, err := Parse(Tokenize([]byte("SELECT id FROM t WHERE " + condition)))
+		if err != nil {
+			t.Fatalf("parse returned an error for %s but should not have: %s", condition, err.Error())
+		}
+
+		_, err = operation.(*SelectOperation).getData(NewDatabase("", table))
+		if err == nil || GetError(err).Category != ERROR_TYPE {
+			t.Fatalf("get should have returned a type error for %s, got %v", condition, err)
+		}
+
+		if err := table.Delete(operation.(*SelectOperation).Condition); err == nil || table.getRowCount() != 3 {
+			t.Fatalf("delete should have returned an error for %s", condition)
+		}
+	}
+}
+
+func TestConditionUnknownColumn(t *testing.T) {
+	table := &Table{Name: "t", Columns: []*Column{
+		{Name: "id", Type: TYPE_INT, Values: NewValues("1", "2", "3")},
+		{Name: "name", Type: TYPE_VARCHAR, Values: NewValues("a", "b", "c")},
+	}}
+
+	tests := []struct {
+		condition string
+		position  int
+	}{
+		{"id > 1 AND nope = 1", 9},
+		{"id > 1 OR nope IS NULL", 9},
+		{"id > 1 OR nope IN (1)", 9},
+		{"id > 1 OR nope LIKE 'a'", 9},
+		{"id > 1 OR nope BETWEEN 1 AND 2", 9},
+		{"id > 1 OR nope = name", 9},
+		{"id > 1 OR name = nope", 11},
+	}
+
+	for _, test := range tests {
+		query := "SELECT id FROM t WHERE " + test.condition
+		operation, err := Parse(Tokenize([]byte(query)))
+		if err != nil {
+			t.Fatalf("parse returned an error but should not have: %s", err.Error())
+		}
+
+		_, err = operation.(*SelectOperation).getData(NewDatabase("", table))
+		sqlError := GetError(err)
+		if err == nil || sqlError.Category != ERROR_NOT_FOUND || sqlError.Position != test.position || sqlError.Offset != strings.Index(query, "nope") {
+			t.Fatalf("unknown column should have been found at token %d for %s, got %+v", test.position, test.condition, sqlError)
+		}
+	}
+
+	operation, _ := Parse(Tokenize([]byte("DELETE FROM t WHERE nope = 1")))
+	err := table.Delete(operation.(*DeleteOperation).Condition)
+	if err == nil || GetError(err).Category != ERROR_NOT_FOUND || len(table.Columns[0].Values) != 3 {
+		t.Fatalf("delete should have failed for an unknown column, got %v", err)
+	}
+}
@@ -1,149 +1,597 @@
-package sql
-
-import (
-	"encoding/json"
-	"fmt"
-	"io/fs"
-	"os"
-	"path/filepath"
-	"slices"
-	"strings"
-)
-
-// Represents a single databse
-type Database struct {
-	rootPath string   // Database location on the disk
-	tables   []*Table // Database tables
-}
-
-// Create a new database.
-//   - rootPath defines the location of the saved files
-func NewDatabase(rootPath string, tables ...*Table) *Database {
-	return &Database{
-		rootPath: rootPath,
-		tables:   tables,
-	}
-}
-
-// Represents a metadata of the database
-type InformationSchema struct {
-	Tables []string `json:"tables"`
-}
-
-// Create a new information_schema
-func NewInformationSchema(database *Database) *InformationSchema {
-	return &InformationSchema{
-		Tables: Map(database.tables, func(table *Table) string { return table.Name }),
-	}
-}
-
-// Get a table from the database by name
-func (database *Database) Get(tableName string) (*Table, error) {
-	index := slices.IndexFunc(database.tables, func(t *Table) bool { return t.Name == tableName })
-	if index == -1 {
-		return nil, fmt.Errorf("table not found: %s", tableName)
-	}
-
-	return database.tables[index], nil
-}
-
-// Create a new empty table in the database
-func (database *Database) Create(tableName string, data []ColData) error {
-	index := slices.IndexFunc(database.tables, func(t *Table) bool { return t.Name == tableName })
-	if index != -1 {
-		return fmt.Errorf("table already exists: %s", tableName)
-	}
-
-	columns := []*Column{}
-	for _, colData := range data {
-		columns = append(columns, &Column{
-			Name:   colData.ColName,
-			Type:   colData.ColType,
-			Values: []string{},
-		})
-	}
-
-	database.tables = append(database.tables, &Table{
-		Name:    tableName,
-		Columns: columns,
-	})
-
-	return nil
-}
-
-// Delete a table from the database
-func (database *Database) Delete(tableName string) error {
-	index := slices.IndexFunc(database.tables, func(t *Table) bool { return t.Name == tableName })
-	if index == -1 {
-		return fmt.Errorf("table not found: %s", tableName)
-	}
-
-	database.tables = slices.Delete(database.tables, index, index+1)
-	return nil
-}
-
-// Write database to disk
-func (database *Database) Save() error {
-	fmt.Printf("Save: %s\n", database.rootPath)
-
-	files, err := os.ReadDir(database.rootPath)
-	if err != nil {
-		return err
-	}
-
-	for _, file := range files {
-		filePath := filepath.Join(database.rootPath, file.Name())
-		if !strings.HasSuffix(filePath, ".json") {
-			continue
-		}
-
-		os.Remove(filePath)
-	}
-
-	for _, table := range database.tables {
-		filePath := filepath.Join(database.rootPath, fmt.Sprintf("%s.json", table.Name))
-		data, err := json.Marshal(table)
-		if err != nil {
-			return err
-		}
-
-		err = os.WriteFile(filePath, data, fs.ModePerm)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// Read database from disk
-func (database *Database) Load() error {
-	fmt.Printf("Load: %s\n", database.rootPath)
-
-	files, err := os.ReadDir(database.rootPath)
-	if err != nil {
-		return err
-	}
-
-	for _, file := range files {
-		filePath := filepath.Join(database.rootPath, file.Name())
-		if !strings.HasSuffix(filePath, ".json") {
-			continue
-		}
-
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			return err
-		}
-
-		table := &Table{}
-		err = json.Unmarshal(data, table)
-		if err != nil {
-			return err
-		}
-
-		database.tables = append(database.tables, table)
-	}
-
-	return nil
-}
+package sql
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Represents a single databse
+type Database struct {
+	rootPath string           // Database location on the disk, empty if the database is kept only in memory
+	tables   []*Table         // Database tables
+	version  atomic.Int64     // Number of changes, used to detect changes made during a transaction
+	versions map[string]int64 // Version of the last change of each table, used to detect changes to the tables of a transaction
+	manifest *Manifest        // Manifest of the last save, lists the table files on the disk
+	dirty    map[string]bool  // Names of the tables changed after the last save
+	log      *WriteAheadLog   // Log of the changes not saved in the table files yet, nil until the database is loaded
+	changes  []*Change        // Changes kept by a copy of the database for a transaction, nil if the changes are not kept
+	mutex    sync.RWMutex     // Guards the tables, locked for reading by operations and for writing when tables are created, deleted or saved
+	logMutex sync.Mutex       // Guards the log and the kept changes, allows only one record to be written at a time
+}
+
+// Create a new database.
+//   - rootPath defines the location of the saved files, an empty path keeps the database only in memory
+func NewDatabase(rootPath string, tables ...*Table) *Database {
+	return &Database{
+		rootPath: rootPath,
+		tables:   tables,
+		manifest: &Manifest{Tables: []ManifestTable{}},
+		dirty:    map[string]bool{},
+		versions: map[string]int64{},
+	}
+}
+
+// Represents a metadata of the database
+type InformationSchema struct {
+	Tables []string `json:"tables"`
+}
+
+// Create a new information_schema
+func NewInformationSchema(database *Database) *InformationSchema {
+	database.mutex.RLock()
+	defer database.mutex.RUnlock()
+
+	return &InformationSchema{
+		Tables: Map(database.tables, func(table *Table) string { return table.Name }),
+	}
+}
+
+// Get a table from the database by name.
+// The table is not locked, operations should access tables with read and write
+func (database *Database) Get(tableName string) (*Table, error) {
+	index := slices.IndexFunc(database.tables, func(t *Table) bool { return t.Name == tableName })
+	if index == -1 {
+		return nil, newError(ERROR_NOT_FOUND, "table not found: %s", tableName)
+	}
+
+	return database.tables[index], nil
+}
+
+// Create a new empty table in the database
+func (database *Database) Create(tableName string, data []ColData) error {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	index := slices.IndexFunc(database.tables, func(t *Table) bool { return t.Name == tableName })
+	if index != -1 {
+		return newError(ERROR_CONSTRAINT, "table already exists: %s", tableName)
+	}
+
+	columns := []*Column{}
+	for _, colData := range data {
+		columns = append(columns, &Column{
+			Name:       colData.ColName,
+			Type:       colData.ColType,
+			PrimaryKey: colData.PrimaryKey,
+			NotNull:    colData.NotNull,
+			Unique:     colData.Unique,
+			Default:    colData.Default,
+			Values:     []Value{},
+		})
+	}
+
+	primaryKeys := slices.DeleteFunc(slices.Clone(columns), func(col *Column) bool { return !col.PrimaryKey })
+	if len(primaryKeys) > 1 {
+		return newError(ERROR_CONSTRAINT, "table can have only one primary key: %s", tableName)
+	}
+
+	for i, col := range columns {
+		defaultValue, err := col.coerce(col.Default)
+		if err != nil {
+			return err
+		}
+
+		col.Default = defaultValue
+		if slices.ContainsFunc(columns[:i], func(other *Column) bool { return other.Name == col.Name }) {
+			return newError(ERROR_CONSTRAINT, "duplicate column name in table %s: %s", tableName, col.Name)
+		}
+	}
+
+	err := database.record([]*Change{{Type: CHANGE_CREATE, Table: tableName, Columns: Map(columns, func(col *Column) *Column {
+		clone := *col
+		clone.Values = []Value{}
+		return &clone
+	})}})
+
+	if err != nil {
+		return err
+	}
+
+	database.tables = append(database.tables, &Table{
+		Name:    tableName,
+		Columns: columns,
+	})
+
+	return nil
+}
+
+// Delete a table from the database
+func (database *Database) Delete(tableName string) error {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	index := slices.IndexFunc(database.tables, func(t *Table) bool { return t.Name == tableName })
+	if index == -1 {
+		return newError(ERROR_NOT_FOUND, "table not found: %s", tableName)
+	}
+
+	if err := database.record([]*Change{{Type: CHANGE_DROP, Table: tableName}}); err != nil {
+		return err
+	}
+
+	database.tables = slices.Delete(database.tables, index, index+1)
+	return nil
+}
+
+// Create a new index on a table in the database, index names are unique in the database
+func (database *Database) CreateIndex(tableName string, index *Index) error {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	if IsTrueForAny(database.tables, func(t *Table) bool {
+		return slices.ContainsFunc(t.Indexes, func(i *Index) bool { return i.Name == index.Name })
+	}) {
+		return newError(ERROR_CONSTRAINT, "index already exists: %s", index.Name)
+	}
+
+	table, err := database.Get(tableName)
+	if err != nil {
+		return err
+	}
+
+	err = table.addIndex(index)
+	if err != nil {
+		return err
+	}
+
+	err = database.record([]*Change{{Type: CHANGE_CREATE_INDEX, Table: tableName, Index: index.definition()}})
+	if err != nil {
+		table.removeIndex(index.Name)
+	}
+
+	return err
+}
+
+// Delete an index from the database
+func (database *Database) DeleteIndex(indexName string) error {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	for _, table := range database.tables {
+		if !slices.ContainsFunc(table.Indexes, func(index *Index) bool { return index.Name == indexName }) {
+			continue
+		}
+
+		if err := database.record([]*Change{{Type: CHANGE_DROP_INDEX, Table: table.Name, Index: &Index{Name: indexName}}}); err != nil {
+			return err
+		}
+
+		table.removeIndex(indexName)
+		return nil
+	}
+
+	return newError(ERROR_NOT_FOUND, "index not found: %s", indexName)
+}
+
+// Read tables of the database, the tables are locked for reading while read is called
+func (database *Database) read(tableNames []string, read func() error) error {
+	database.mutex.RLock()
+	defer database.mutex.RUnlock()
+
+	return database.lockTables(tableNames, []string{}, read)
+}
+
+// Change tables of the database and record the changes if the change succeeds.
+// Tables in tableNames are locked for writing and tables in readTableNames for reading while change is called.
+// The database is saved if the write-ahead log is full
+func (database *Database) write(tableNames []string, readTableNames []string, change func() error) error {
+	isLogFull, err := database.change(tableNames, readTableNames, change)
+	if err != nil || !isLogFull {
+		return err
+	}
+
+	return database.Save()
+}
+
+// Change tables of the database and record the changes, see write.
+// Returns true if the write-ahead log is full after the change
+func (database *Database) change(tableNames []string, readTableNames []string, change func() error) (bool, error) {
+	database.mutex.RLock()
+	defer database.mutex.RUnlock()
+
+	err := database.lockTables(readTableNames, tableNames, func() error {
+		err := change()
+		if err != nil {
+			return err
+		}
+
+		changes := []*Change{}
+		for _, tableName := range tableNames {
+			table, err := database.Get(tableName)
+			if err != nil {
+				return err
+			}
+
+			changes = append(changes, table.changes...)
+			table.changes = nil
+		}
+
+		// CHANGES THAT ARE NOT IN THE LOG ARE REVERTED SO THAT THE MEMORY MATCHES THE LOG
+		err = database.record(changes)
+		if err != nil {
+			revertChanges(changes)
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return database.isLogFull(), nil
+}
+
+// Check if the write-ahead log has enough records to save the database, false if there is no log
+func (database *Database) isLogFull() bool {
+	database.logMutex.Lock()
+	defer database.logMutex.Unlock()
+
+	return database.log != nil && database.log.isFull()
+}
+
+// Record changes of the tables, the database must be locked.
+// The changes are written to the write-ahead log or kept if the database is a copy for a transaction.
+// Nothing is recorded if the log cannot be written, the caller must then revert the changes or not make them at all
+func (database *Database) record(changes []*Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	database.logMutex.Lock()
+	defer database.logMutex.Unlock()
+
+	if database.rootPath != "" {
+		if database.log == nil {
+			return fmt.Errorf("changes could not be recorded, the database is not loaded")
+		}
+
+		if err := database.log.append(changes); err != nil {
+			return err
+		}
+	}
+
+	version := database.version.Add(1)
+	for _, change := range changes {
+		database.dirty[change.Table] = true
+		database.versions[change.Table] = version
+	}
+
+	if database.changes != nil {
+		database.changes = append(database.changes, changes...)
+	}
+
+	return nil
+}
+
+// Lock tables, call a function and unlock the tables.
+// Tables are locked in the order of their names so operations locking many tables cannot deadlock.
+// A table in both reads and writes is locked for writing
+func (database *Database) lockTables(reads []string, writes []string, call func() error) error {
+	tableNames := slices.Concat(reads, writes)
+	slices.Sort(tableNames)
+	for _, tableName := range slices.Compact(tableNames) {
+		table, err := database.Get(tableName)
+		if err != nil {
+			return err
+		}
+
+		if slices.Contains(writes, tableName) {
+			table.mutex.Lock()
+			defer table.mutex.Unlock()
+		} else {
+			table.mutex.RLock()
+			defer table.mutex.RUnlock()
+		}
+	}
+
+	return call()
+}
+
+// Create a copy of the database that is kept only in memory.
+// Changes to the copy are not visible in the original database
+func (database *Database) clone() *Database {
+	database.mutex.RLock()
+	defer database.mutex.RUnlock()
+
+	clone := &Database{changes: []*Change{}, dirty: map[string]bool{}, versions: map[string]int64{}}
+	clone.version.Store(database.version.Load())
+	clone.tables = Map(database.tables, func(table *Table) *Table {
+		table.mutex.RLock()
+		defer table.mutex.RUnlock()
+
+		return table.clone()
+	})
+
+	return clone
+}
+
+// Replace the tables changed in a copy with the tables of the copy and record the changes kept by the copy.
+// Fails if any of the changed tables was changed in the database after the version, changes to other tables are kept.
+// The database is saved if the write-ahead log is full, see write
+func (database *Database) replace(clone *Database, version int64) error {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	clone.mutex.Lock()
+	defer clone.mutex.Unlock()
+
+	tableNames := Map(clone.changes, func(change *Change) string { return change.Table })
+	slices.Sort(tableNames)
+	tableNames = slices.Compact(tableNames)
+	for _, tableName := range tableNames {
+		if database.versions[tableName] > version {
+			return newError(ERROR_CONSTRAINT, "table %s was changed by another operation", tableName)
+		}
+	}
+
+	for _, change := range clone.changes {
+		if change.Type != CHANGE_CREATE_INDEX {
+			continue
+		}
+
+		if IsTrueForAny(database.tables, func(t *Table) bool {
+			return !slices.Contains(tableNames, t.Name) && slices.ContainsFunc(t.Indexes, func(i *Index) bool { return i.Name == change.Index.Name })
+		}) {
+			return newError(ERROR_CONSTRAINT, "index %s was created by another operation", change.Index.Name)
+		}
+	}
+
+	if err := database.record(clone.changes); err != nil {
+		return err
+	}
+
+	for _, tableName := range tableNames {
+		index := slices.IndexFunc(database.tables, func(t *Table) bool { return t.Name == tableName })
+		cloneIndex := slices.IndexFunc(clone.tables, func(t *Table) bool { return t.Name == tableName })
+		switch {
+		case index >= 0 && cloneIndex >= 0:
+			database.tables[index] = clone.tables[cloneIndex]
+		case index >= 0:
+			database.tables = slices.Delete(database.tables, index, index+1)
+		case cloneIndex >= 0:
+			database.tables = append(database.tables, clone.tables[cloneIndex])
+		}
+	}
+
+	if !database.isLogFull() {
+		return nil
+	}
+
+	return database.save()
+}
+
+// Write the tables changed after the last save to the disk and empty the write-ahead log.
+// Does nothing if the database is kept only in memory
+func (database *Database) Save() error {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	return database.save()
+}
+
+// Write the changed tables to the disk and empty the write-ahead log, the database must be locked for writing.
+// Files of the unchanged tables are listed in the new manifest as they are, files of the deleted tables are removed
+func (database *Database) save() error {
+	if database.rootPath == "" {
+		return nil
+	}
+
+	fmt.Printf("Save: %s\n", database.rootPath)
+
+	manifest := &Manifest{Generation: database.manifest.Generation + 1, Tables: []ManifestTable{}}
+	if database.log != nil {
+		manifest.Sequence = database.log.sequence
+	}
+
+	for _, table := range database.tables {
+		indexes := Map(table.Indexes, (*Index).definition)
+		index := slices.IndexFunc(database.manifest.Tables, func(t ManifestTable) bool { return t.Table == table.Name })
+		if index != -1 && !database.dirty[table.Name] {
+			manifest.Tables = append(manifest.Tables, ManifestTable{Table: table.Name, File: database.manifest.Tables[index].File, Indexes: indexes})
+			continue
+		}
+
+		fileName := fmt.Sprintf("%s.%d%s", table.Name, manifest.Generation, TABLE_FILE_EXTENSION)
+		data, err := encodeTable(table)
+		if err != nil {
+			return err
+		}
+
+		err = writeFileAtomic(filepath.Join(database.rootPath, fileName), data)
+		if err != nil {
+			return err
+		}
+
+		manifest.Tables = append(manifest.Tables, ManifestTable{Table: table.Name, File: fileName, Indexes: indexes})
+	}
+
+	err := writeManifest(database.rootPath, manifest)
+	if err != nil {
+		return err
+	}
+
+	database.manifest = manifest
+	database.dirty = map[string]bool{}
+	if database.log != nil {
+		err = database.log.clear()
+		if err != nil {
+			return err
+		}
+	}
+
+	return database.removeUnusedFiles(manifest)
+}
+
+// Remove the files not listed in the manifest from the disk, such as the files of the previous save
+func (database *Database) removeUnusedFiles(manifest *Manifest) error {
+	fileNames, err := getUnusedFiles(database.rootPath, manifest)
+	if err != nil {
+		return err
+	}
+
+	for _, fileName := range fileNames {
+		os.Remove(filepath.Join(database.rootPath, fileName))
+	}
+
+	return nil
+}
+
+// Read database from disk and apply the changes in the write-ahead log, does nothing if the database is kept only in memory.
+// Changes are written to the disk only after the database is loaded. The database is left empty if it cannot be loaded
+func (database *Database) Load() error {
+	if database.rootPath == "" {
+		return nil
+	}
+
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	fmt.Printf("Load: %s\n", database.rootPath)
+
+	err := database.load()
+	if err != nil {
+		database.unload()
+	}
+
+	return err
+}
+
+// Read the database from the disk, see Load. The database must be locked for writing
+func (database *Database) load() error {
+	manifest, err := readManifest(database.rootPath)
+	if err != nil {
+		return err
+	}
+
+	isLegacy := manifest == nil
+	if isLegacy {
+		manifest = &Manifest{Tables: []ManifestTable{}}
+		err = database.loadLegacy()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, manifestTable := range manifest.Tables {
+		table, err := readTable(filepath.Join(database.rootPath, manifestTable.File))
+		if err != nil {
+			return err
+		}
+
+		table.Indexes = manifestTable.Indexes
+		database.tables = append(database.tables, table)
+	}
+
+	log, records, err := openWriteAheadLog(filepath.Join(database.rootPath, WAL_FILE_NAME), manifest.Sequence)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		for _, change := range record.Changes {
+			err = change.apply(database)
+			if err != nil {
+				log.close()
+				return err
+			}
+
+			database.dirty[change.Table] = true
+		}
+	}
+
+	for _, table := range database.tables {
+		for _, index := range table.Indexes {
+			err = table.buildIndex(index)
+			if err != nil {
+				log.close()
+				return err
+			}
+		}
+	}
+
+	database.log = log
+	database.manifest = manifest
+	if isLegacy && len(database.tables) > 0 || IsTrueForAny(manifest.Tables, func(table ManifestTable) bool { return isLegacyFile(table.File) }) {
+		return database.migrate()
+	}
+
+	return nil
+}
+
+// Reset the database to the state before it was loaded, the log is closed.
+// Files on the disk are not changed, so the database can be loaded again
+func (database *Database) unload() {
+	if database.log != nil {
+		database.log.close()
+	}
+
+	database.tables = nil
+	database.log = nil
+	database.manifest = &Manifest{Tables: []ManifestTable{}}
+	database.dirty = map[string]bool{}
+}
+
+// Rewrite the tables read from json files saved by an older version as table files
+func (database *Database) migrate() error {
+	fmt.Printf("Migrate: %s\n", database.rootPath)
+
+	for _, table := range database.tables {
+		index := slices.IndexFunc(database.manifest.Tables, func(t ManifestTable) bool { return t.Table == table.Name })
+		if index == -1 || isLegacyFile(database.manifest.Tables[index].File) {
+			database.dirty[table.Name] = true
+		}
+	}
+
+	return database.save()
+}
+
+// Read every json file in the database directory as a table, used for databases saved without a manifest.
+// The files are replaced by the files listed in a manifest on the next save
+func (database *Database) loadLegacy() error {
+	files, err := os.ReadDir(database.rootPath)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		filePath := filepath.Join(database.rootPath, file.Name())
+		if !strings.HasSuffix(filePath, ".json") {
+			continue
+		}
+
+		table, err := readTable(filePath)
+		if err != nil {
+			return err
+		}
+
+		database.tables = append(database.tables, table)
+	}
+
+	return nil
+}
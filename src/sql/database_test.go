@@ -1,18 +1,118 @@
-package sql
-
-import (
-	"testing"
-)
-
-func TestDatabaseGet(t *testing.T) {
-	database := NewDatabase("", &Table{Name: "valid"})
-	table, err := database.Get("valid")
-	if table == nil || err != nil {
-		t.Fatal("table should be found but was not")
-	}
-
-	_, err = database.Get("invalid")
-	if err == nil {
-		t.Fatal("error was not thrown but should have")
-	}
-}
+package sql
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestDatabaseGet(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "valid"})
+	table, err := database.Get("valid")
+	if table == nil || err != nil {
+		t.Fatal("table should be found but was not")
+	}
+
+	_, err = database.Get("invalid")
+	if err == nil {
+		t.Fatal("error was not thrown but should have")
+	}
+}
+
+func TestDatabaseSaveLoad(t *testing.T) {
+	rootPath := t.TempDir()
+	database := NewDatabase(rootPath, &Table{Name: "table", Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: NewValues("1")}}})
+	err := os.WriteFile(filepath.Join(rootPath, "table.json"), []byte("{}"), fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = database.Save()
+	if err != nil {
+		t.Fatal("save returned an error but should not have")
+	}
+
+	err = os.WriteFile(filepath.Join(rootPath, "other.2.json"), []byte("{}"), fs.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewDatabase(rootPath)
+	err = loaded.Load()
+	if err != nil || len(loaded.tables) != 1 || len(loaded.tables[0].Columns[0].Values) != 1 {
+		t.Fatal("load should have read only the tables listed in the manifest")
+	}
+
+	_, err = os.Stat(filepath.Join(rootPath, "table.json"))
+	if err == nil {
+		t.Fatal("save should have removed the files of the previous save")
+	}
+}
+
+func TestDatabaseSaveChanged(t *testing.T) {
+	rootPath := t.TempDir()
+	database := NewDatabase(rootPath)
+	database.Load()
+	database.Create("changed", []ColData{{ColName: "col1", ColType: TYPE_INT}})
+	database.Create("unchanged", []ColData{{ColName: "col1", ColType: TYPE_INT}})
+	database.Create("dropped", []ColData{{ColName: "col1", ColType: TYPE_INT}})
+	database.Save()
+
+	(&InsertOperation{TableName: "changed", Rows: [][]Value{NewValues("1")}}).Call(database)
+	database.Delete("dropped")
+	err := database.Save()
+	if err != nil {
+		t.Fatal("save returned an error but should not have")
+	}
+
+	manifest, _ := readManifest(rootPath)
+	if len(manifest.Tables) != 2 || manifest.Tables[0].File != "changed.2.tbl" || manifest.Tables[1].File != "unchanged.1.tbl" {
+		t.Fatal("save should have written only the changed table")
+	}
+
+	_, err = os.Stat(filepath.Join(rootPath, "dropped.1.tbl"))
+	if err == nil {
+		t.Fatal("save should have removed the file of the dropped table")
+	}
+}
+
+func TestDatabaseConcurrent(t *testing.T) {
+	database := NewDatabase(t.TempDir(), &Table{Name: "table", Columns: []*Column{{Name: "col1", Type: TYPE_INT}}})
+	err := database.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewTransactionManager(database)
+	operations := []Operation{
+		&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("1"), NewValues("2")}},
+		&SelectOperation{TableName: "table", Projections: []*Projection{{ColumnName: "*"}}},
+		&UpdateOperation{TableName: "table", Assignments: []*Assignment{{ColumnName: "col1", Expression: &LiteralExpression{Value: NewValue("3")}}}},
+		&DeleteOperation{TableName: "table", Condition: &Filter{ColumnName: "col1", Operator: EQUAL, CompareValue: NewValue("3")}},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := manager.Execute(operations[i%len(operations)], "")
+			if err != nil {
+				t.Error(err)
+			}
+
+			transaction, err := manager.Begin()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			manager.Execute(operations[0], transaction.Id)
+			manager.Commit(transaction.Id)
+		}()
+	}
+
+	wg.Wait()
+}
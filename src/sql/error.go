@@ -0,0 +1,199 @@
+package sql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Enum to represent a category of an error, values are prefixed with ERROR
+type ErrorCategory int
+
+const (
+	ERROR_INTERNAL   ErrorCategory = iota // Unexpected error, for example a file could not be written
+	ERROR_SYNTAX                          // Query could not be parsed or is not valid
+	ERROR_NOT_FOUND                       // Table, column, index or transaction does not exist
+	ERROR_CONSTRAINT                      // Change would break a constraint or conflicts with the existing data
+	ERROR_TYPE                            // Value is not valid for the type of a column or an operation
+)
+
+// Get a string value of an error category
+func (category ErrorCategory) ToString() string {
+	switch category {
+	case ERROR_SYNTAX:
+		return "syntax"
+	case ERROR_NOT_FOUND:
+		return "not_found"
+	case ERROR_CONSTRAINT:
+		return "constraint"
+	case ERROR_TYPE:
+		return "type"
+	}
+
+	return "internal"
+}
+
+// Get the http status code of an error category
+func (category ErrorCategory) StatusCode() int {
+	switch category {
+	case ERROR_SYNTAX, ERROR_TYPE:
+		return http.StatusBadRequest
+	case ERROR_NOT_FOUND:
+		return http.StatusNotFound
+	case ERROR_CONSTRAINT:
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Represents an error of an sql operation with a category.
+// Errors found at a token have the location of the token in the query and a snippet of the line with a caret under the token
+type Error struct {
+	Category  ErrorCategory
+	Message   string
+	Statement int    // Number of the statement in the query starting from 1, 0 if the error is not related to a statement
+	Position  int    // Index of the token the error was found at, -1 if the error is not related to a token
+	Line      int    // Line of the token starting from 1, 0 if the error is not related to a token
+	Column    int    // Column of the token in characters starting from 1, 0 if the error is not related to a token
+	Offset    int    // Byte offset of the token in the query, -1 if the error is not related to a token
+	Snippet   string // Line of the query with a caret under the token
+}
+
+// Create a new error of a category that is not related to a token
+func newError(category ErrorCategory, format string, args ...any) *Error {
+	return &Error{Category: category, Message: fmt.Sprintf(format, args...), Position: -1, Offset: -1}
+}
+
+// Create a new syntax error found at a token, an index after the last token is used for an unexpected end of a query.
+// The error is located right after the last token in that case
+func newSyntaxError(tokens []*Token, index int, format string, args ...any) *Error {
+	err := &Error{Category: ERROR_SYNTAX, Message: fmt.Sprintf(format, args...), Position: index, Offset: -1}
+	if len(tokens) == 0 {
+		return err
+	}
+
+	token := tokens[min(index, len(tokens)-1)]
+	err.Line, err.Column, err.Offset = token.Line, token.Column, token.Offset
+	if index >= len(tokens) {
+		err.Column += utf8.RuneCount(token.source[token.Offset:token.End])
+		err.Offset = token.End
+	}
+
+	err.Snippet = formatSnippet(token.source, err.Offset, err.Column)
+	return err
+}
+
+// Get an error as an sql error located at a token, the position is the index of the token in the whole input.
+// The error is returned as it is if there is no token
+func locateError(err error, token *Token) error {
+	if token == nil {
+		return err
+	}
+
+	sqlError := *GetError(err)
+	sqlError.Position, sqlError.Line, sqlError.Column, sqlError.Offset = token.Index, token.Line, token.Column, token.Offset
+	sqlError.Snippet = formatSnippet(token.source, token.Offset, token.Column)
+	return &sqlError
+}
+
+// Format the line of the input at an offset with a caret under the column of the offset
+func formatSnippet(source []byte, offset int, column int) string {
+	start := bytes.LastIndexByte(source[:offset], '\n') + 1
+	end := bytes.IndexByte(source[offset:], '\n')
+	if end == -1 {
+		end = len(source)
+	} else {
+		end += offset
+	}
+
+	line := strings.TrimRight(string(source[start:end]), "\r")
+	indent := []rune{}
+	for i, r := range []rune(line) {
+		if i >= column-1 {
+			break
+		}
+
+		if r == '\t' {
+			indent = append(indent, '\t')
+		} else {
+			indent = append(indent, ' ')
+		}
+	}
+
+	return fmt.Sprintf("%s\n%s^", line, string(indent))
+}
+
+// Get the message of the error, the location and the snippet are included for errors found at a token
+func (err *Error) Error() string {
+	if err.Line == 0 {
+		return err.Message
+	}
+
+	return fmt.Sprintf("%s at line %d, column %d\n%s", err.Message, err.Line, err.Column, err.Snippet)
+}
+
+// Write the error as a json object of the category code, the message, the statement and the location of the token,
+// unknown statement and location are written as null
+func (err *Error) MarshalJSON() ([]byte, error) {
+	data := &struct {
+		Code      string  `json:"code"`
+		Message   string  `json:"message"`
+		Statement *int    `json:"statement"`
+		Position  *int    `json:"position"`
+		Line      *int    `json:"line"`
+		Column    *int    `json:"column"`
+		Offset    *int    `json:"offset"`
+		Snippet   *string `json:"snippet"`
+	}{
+		Code:    err.Category.ToString(),
+		Message: err.Message,
+	}
+
+	if err.Statement > 0 {
+		data.Statement = &err.Statement
+	}
+
+	if err.Position >= 0 {
+		data.Position = &err.Position
+	}
+
+	if err.Line > 0 {
+		data.Line, data.Column, data.Offset, data.Snippet = &err.Line, &err.Column, &err.Offset, &err.Snippet
+	}
+
+	return json.Marshal(data)
+}
+
+// Get an error as an sql error, the message of the outermost error is kept if the sql error is wrapped.
+// Errors without a category are internal errors
+func GetError(err error) *Error {
+	var sqlError *Error
+	if !errors.As(err, &sqlError) {
+		return newError(ERROR_INTERNAL, "%s", err.Error())
+	}
+
+	if sqlError == err {
+		return sqlError
+	}
+
+	wrapped := *sqlError
+	wrapped.Message = err.Error()
+	return &wrapped
+}
+
+// Get an error as an sql error of a statement in a query.
+// The position of the token is moved by the index of the first token of the statement, so it is counted from the start of the query
+func getStatementError(err error, statement int, start int) *Error {
+	sqlError := *GetError(err)
+	sqlError.Statement = statement
+	if sqlError.Position >= 0 {
+		sqlError.Position += start
+	}
+
+	return &sqlError
+}
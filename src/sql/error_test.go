@@ -0,0 +1,88 @@
+package sql
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGetError(t *testing.T) {
+	err := GetError(fmt.Errorf("transaction could not be committed, %w", newError(ERROR_CONSTRAINT, "conflict")))
+	if err.Category != ERROR_CONSTRAINT || err.Message != "transaction could not be committed, conflict" {
+		t.Fatal("wrapped error should keep the category and the outer message")
+	}
+
+	err = GetError(fmt.Errorf("file could not be written"))
+	if err.Category != ERROR_INTERNAL || err.Position != -1 {
+		t.Fatal("error without a category should be internal")
+	}
+
+	_, parseErr := Parse(Tokenize([]byte("SELECT * FROM")))
+	err = GetError(parseErr)
+	if err.Category != ERROR_SYNTAX || err.Position != 3 {
+		t.Fatal("parser error should be a syntax error at the end of the tokens")
+	}
+
+	_, parseErr = Parse(Tokenize([]byte("SELECT *\nFROM artists a SORT")))
+	err = GetError(parseErr)
+	if err.Line != 2 || err.Column != 16 || err.Offset != 24 || err.Snippet != "FROM artists a SORT\n               ^" {
+		t.Fatal("syntax error should have the location of the token and a caret under it")
+	}
+
+	_, parseErr = Parse(Tokenize([]byte("INSERT INTO t VALUES ('it''s'")))
+	err = GetError(parseErr)
+	if err.Column != 30 || err.Offset != 29 || err.Snippet != "INSERT INTO t VALUES ('it''s'\n                             ^" {
+		t.Fatalf("unexpected end should be located right after the last token, got %+v", err)
+	}
+}
+
+func TestStatementError(t *testing.T) {
+	operations, err := ParseScript(Tokenize([]byte("SELECT * FROM artists;; DELETE FROM artists;")))
+	if err != nil || len(operations) != 2 {
+		t.Fatal("script should have two statements, empty statements should be skipped")
+	}
+
+	_, parseErr := ParseScript(Tokenize([]byte("SELECT * FROM artists;\nDELETE artists")))
+	sqlError := GetError(parseErr)
+	if sqlError.Statement != 2 || sqlError.Position != 6 || sqlError.Line != 2 || sqlError.Column != 8 {
+		t.Fatal("syntax error should have the number of the statement and the position in the script")
+	}
+}
+
+func TestExecutionErrorPosition(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "t", Columns: []*Column{
+		{Name: "id", Type: TYPE_INT, Values: NewValues("1", "2")},
+		{Name: "age", Type: TYPE_INT, Values: NewValues("30", "40")},
+		{Name: "name", Type: TYPE_VARCHAR, Values: NewValues("a", "b")},
+	}})
+
+	tests := []struct {
+		query    string
+		category ErrorCategory
+		position int
+	}{
+		{"SELECT * FROM t WHERE age = 'x'", ERROR_TYPE, 5},
+		{"SELECT * FROM t WHERE age IN (1, 'x')", ERROR_TYPE, 5},
+		{"SELECT nope FROM t", ERROR_NOT_FOUND, 1},
+		{"SELECT id, COUNT(nope) FROM t GROUP BY id", ERROR_NOT_FOUND, 3},
+		{"SELECT COUNT(*) FROM t GROUP BY nope", ERROR_NOT_FOUND, 9},
+		{"SELECT name, COUNT(*) FROM t GROUP BY id", ERROR_SYNTAX, 1},
+		{"UPDATE t SET nope = 1", ERROR_NOT_FOUND, 3},
+		{"UPDATE t SET age = 'x'", ERROR_TYPE, 3},
+		{"UPDATE t (id, nope) VALUES (1, 2)", ERROR_NOT_FOUND, 5},
+		{"INSERT INTO t VALUES (3, 'x', 'c')", ERROR_TYPE, 7},
+		{"INSERT INTO t (age) VALUES (1), ('x')", ERROR_TYPE, 12},
+	}
+
+	for _, test := range tests {
+		operation, err := Parse(Tokenize([]byte(test.query)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = operation.Call(database)
+		sqlError := GetError(err)
+		if err == nil || sqlError.Category != test.category || sqlError.Position != test.position {
+			t.Fatalf("error of %s should have been located at token %d, got %+v", test.query, test.position, sqlError)
+		}
+	}
+}
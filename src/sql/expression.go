@@ -0,0 +1,200 @@
+package sql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Base contract of a value expression, evaluated against a single row of a table
+type Expression interface {
+	Evaluate(table *Table, rowIndex int) (Value, ColumnType, error)
+}
+
+// Enum to represent an arithmetic operator, values are prefixed with ARITHMETIC
+type ArithmeticOperator int
+
+const (
+	ARITHMETIC_ADD      ArithmeticOperator = iota // Addition operator +
+	ARITHMETIC_SUBTRACT                           // Subtraction operator -
+	ARITHMETIC_MULTIPLY                           // Multiplication operator *
+	ARITHMETIC_DIVIDE                             // Division operator /
+	ARITHMETIC_MODULO                             // Remainder operator %
+)
+
+// Get an arithmetic operator from a string
+func GetArithmeticOperator(s string) (ArithmeticOperator, error) {
+	switch s {
+	case "+":
+		return ARITHMETIC_ADD, nil
+	case "-":
+		return ARITHMETIC_SUBTRACT, nil
+	case "*":
+		return ARITHMETIC_MULTIPLY, nil
+	case "/":
+		return ARITHMETIC_DIVIDE, nil
+	case "%":
+		return ARITHMETIC_MODULO, nil
+	}
+
+	return -1, fmt.Errorf("invalid arithmetic operator: %s", s)
+}
+
+// Represents a literal value in an expression
+type LiteralExpression struct {
+	Value  Value
+	Quoted bool // Value was written in single quotes, a quoted value is always text
+}
+
+// Evaluate the literal, values that can be read as a whole number are integers and other numbers are floats.
+// Null literal has no type
+func (expression *LiteralExpression) Evaluate(table *Table, rowIndex int) (Value, ColumnType, error) {
+	if expression.Value.IsNull() {
+		return Value{}, -1, nil
+	}
+
+	if expression.Quoted {
+		return expression.Value, TYPE_VARCHAR, nil
+	}
+
+	if _, err := strconv.Atoi(expression.Value.String); err == nil {
+		return expression.Value, TYPE_INT, nil
+	}
+
+	if value, err := strconv.ParseFloat(expression.Value.String, 64); err == nil && !math.IsInf(value, 0) && !math.IsNaN(value) {
+		return expression.Value, TYPE_FLOAT, nil
+	}
+
+	return expression.Value, TYPE_VARCHAR, nil
+}
+
+// Represents a column name in an expression
+type IdentifierExpression struct {
+	Name string
+}
+
+// Evaluate the identifier to the column value of the row, the column must exist
+func (expression *IdentifierExpression) Evaluate(table *Table, rowIndex int) (Value, ColumnType, error) {
+	col, err := table.getColumnByName(expression.Name)
+	if err != nil {
+		return Value{}, -1, err
+	}
+
+	return col.Values[rowIndex], col.Type, nil
+}
+
+// Represents a negated numeric expression, for example -x
+type NegateExpression struct {
+	Expression Expression
+}
+
+// Evaluate the negated value of the inner expression, negation of null is null
+func (expression *NegateExpression) Evaluate(table *Table, rowIndex int) (Value, ColumnType, error) {
+	value, t, err := expression.Expression.Evaluate(table, rowIndex)
+	if err != nil || value.IsNull() {
+		return value, t, err
+	}
+
+	switch t {
+	case TYPE_INT:
+		intValue, _ := strconv.Atoi(value.String)
+		return NewValue(strconv.Itoa(-intValue)), TYPE_INT, nil
+	case TYPE_FLOAT:
+		floatValue, _ := strconv.ParseFloat(value.String, 64)
+		return NewValue(FormatFloat(-floatValue)), TYPE_FLOAT, nil
+	}
+
+	return Value{}, -1, newError(ERROR_TYPE, "cannot negate a value of type %s: %s", t.ToString(), value.String)
+}
+
+// Represents two expressions combined with an arithmetic operator
+type ArithmeticExpression struct {
+	Operator ArithmeticOperator
+	Left     Expression
+	Right    Expression
+}
+
+// Evaluate the arithmetic, both sides must be numeric.
+// The result is an integer if both sides are integers, otherwise a float. Arithmetic with null is null
+func (expression *ArithmeticExpression) Evaluate(table *Table, rowIndex int) (Value, ColumnType, error) {
+	left, leftType, err := expression.Left.Evaluate(table, rowIndex)
+	if err != nil {
+		return Value{}, -1, err
+	}
+
+	right, rightType, err := expression.Right.Evaluate(table, rowIndex)
+	if err != nil {
+		return Value{}, -1, err
+	}
+
+	if left.IsNull() || right.IsNull() {
+		return Value{}, -1, nil
+	}
+
+	if !leftType.IsNumeric() || !rightType.IsNumeric() {
+		return Value{}, -1, newError(ERROR_TYPE, "arithmetic is only supported for numbers: %s, %s", left.String, right.String)
+	}
+
+	if leftType == TYPE_FLOAT || rightType == TYPE_FLOAT {
+		a, _ := strconv.ParseFloat(left.String, 64)
+		b, _ := strconv.ParseFloat(right.String, 64)
+		return expression.calculateFloat(a, b)
+	}
+
+	a, _ := strconv.Atoi(left.String)
+	b, _ := strconv.Atoi(right.String)
+	return expression.calculateInt(a, b)
+}
+
+func (expression *ArithmeticExpression) calculateInt(a int, b int) (Value, ColumnType, error) {
+	switch expression.Operator {
+	case ARITHMETIC_ADD:
+		return NewValue(strconv.Itoa(a + b)), TYPE_INT, nil
+	case ARITHMETIC_SUBTRACT:
+		return NewValue(strconv.Itoa(a - b)), TYPE_INT, nil
+	case ARITHMETIC_MULTIPLY:
+		return NewValue(strconv.Itoa(a * b)), TYPE_INT, nil
+	case ARITHMETIC_DIVIDE, ARITHMETIC_MODULO:
+		if b == 0 {
+			return Value{}, -1, newError(ERROR_TYPE, "division by zero")
+		}
+
+		if expression.Operator == ARITHMETIC_DIVIDE {
+			return NewValue(strconv.Itoa(a / b)), TYPE_INT, nil
+		}
+
+		return NewValue(strconv.Itoa(a % b)), TYPE_INT, nil
+	}
+
+	return Value{}, -1, fmt.Errorf("invalid arithmetic operator")
+}
+
+func (expression *ArithmeticExpression) calculateFloat(a float64, b float64) (Value, ColumnType, error) {
+	switch expression.Operator {
+	case ARITHMETIC_ADD:
+		return NewValue(FormatFloat(a + b)), TYPE_FLOAT, nil
+	case ARITHMETIC_SUBTRACT:
+		return NewValue(FormatFloat(a - b)), TYPE_FLOAT, nil
+	case ARITHMETIC_MULTIPLY:
+		return NewValue(FormatFloat(a * b)), TYPE_FLOAT, nil
+	case ARITHMETIC_DIVIDE, ARITHMETIC_MODULO:
+		if b == 0 {
+			return Value{}, -1, newError(ERROR_TYPE, "division by zero")
+		}
+
+		if expression.Operator == ARITHMETIC_DIVIDE {
+			return NewValue(FormatFloat(a / b)), TYPE_FLOAT, nil
+		}
+
+		return NewValue(FormatFloat(math.Mod(a, b))), TYPE_FLOAT, nil
+	}
+
+	return Value{}, -1, fmt.Errorf("invalid arithmetic operator")
+}
+
+// Represents a single column assignment of an update, for example age = age + 1
+type Assignment struct {
+	ColumnName string
+	Expression Expression
+	Token      *Token // Token of the column in the query, nil if the assignment was not parsed
+}
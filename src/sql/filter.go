@@ -1,24 +1,159 @@
-package sql
-
-// Represents a single sql where expression
-type Filter struct {
-	ColumnName   string
-	Operator     EqualityOperator
-	CompareValue string
-}
-
-// Check if a value is included by the filter
-func (filter *Filter) IsIncluded(value string, t ColumnType) bool {
-	return filter.Operator.Compare(t, value, filter.CompareValue)
-}
-
-// Check if any filter returns true
-func IsTrueForAny(filters []*Filter, match func(filter *Filter) bool) bool {
-	for _, filter := range filters {
-		if match(filter) {
-			return true
-		}
-	}
-
-	return false
-}
+package sql
+
+// Represents a single sql where comparison, a leaf in a condition tree
+type Filter struct {
+	ColumnName   string
+	Function     AggregateFunction // Aggregate function applied to the column, only used in having expressions
+	Operator     EqualityOperator
+	CompareValue Value
+	Token        *Token // Token of the column in the query, nil if the filter was not parsed
+}
+
+// Check if a value is included by the filter
+func (filter *Filter) IsIncluded(value Value, t ColumnType) Truth {
+	return filter.Operator.Compare(t, value, filter.CompareValue)
+}
+
+// Evaluate the filter for a row, comparisons on unknown columns are always false
+func (filter *Filter) Evaluate(table *Table, rowIndex int) Truth {
+	col, err := table.getColumnByName(filter.projection().Name())
+	if err != nil {
+		return TRUTH_FALSE
+	}
+
+	return filter.IsIncluded(col.Values[rowIndex], col.Type)
+}
+
+// Get the column or aggregate function the filter compares
+func (filter *Filter) projection() *Projection {
+	return &Projection{ColumnName: filter.ColumnName, Function: filter.Function, Token: filter.Token}
+}
+
+// Represents an is null or an is not null predicate, a leaf in a condition tree
+type NullFilter struct {
+	ColumnName string
+	Function   AggregateFunction // Aggregate function applied to the column, only used in having expressions
+	IsNot      bool              // True for is not null
+	Token      *Token            // Token of the column in the query, nil if the filter was not parsed
+}
+
+// Evaluate the predicate for a row, the result is never unknown
+func (filter *NullFilter) Evaluate(table *Table, rowIndex int) Truth {
+	col, err := table.getColumnByName(filter.projection().Name())
+	if err != nil {
+		return TRUTH_FALSE
+	}
+
+	return GetTruth(col.Values[rowIndex].IsNull() != filter.IsNot)
+}
+
+// Get the column or aggregate function the predicate checks
+func (filter *NullFilter) projection() *Projection {
+	return &Projection{ColumnName: filter.ColumnName, Function: filter.Function, Token: filter.Token}
+}
+
+// Represents an in predicate `x IN (a, b, c)`, a leaf in a condition tree
+type InFilter struct {
+	ColumnName string
+	Function   AggregateFunction // Aggregate function applied to the column, only used in having expressions
+	Values     []Value
+	Token      *Token // Token of the column in the query, nil if the filter was not parsed
+}
+
+// Check if a value is one of the values of the filter.
+// The result is unknown if the value is null or if it is not found and some of the values are null
+func (filter *InFilter) IsIncluded(value Value, t ColumnType) Truth {
+	truth := TRUTH_FALSE
+	for _, compareValue := range filter.Values {
+		truth = truth.Or(EQUAL.Compare(t, value, compareValue))
+	}
+
+	return truth
+}
+
+// Evaluate the predicate for a row, predicates on unknown columns are always false
+func (filter *InFilter) Evaluate(table *Table, rowIndex int) Truth {
+	col, err := table.getColumnByName(filter.projection().Name())
+	if err != nil {
+		return TRUTH_FALSE
+	}
+
+	return filter.IsIncluded(col.Values[rowIndex], col.Type)
+}
+
+// Get the column or aggregate function the predicate checks
+func (filter *InFilter) projection() *Projection {
+	return &Projection{ColumnName: filter.ColumnName, Function: filter.Function, Token: filter.Token}
+}
+
+// Represents a like predicate `x LIKE 'pattern' [ESCAPE '\']`, a leaf in a condition tree.
+// In the pattern % matches any number of characters and _ matches a single character
+type LikeFilter struct {
+	ColumnName string
+	Function   AggregateFunction // Aggregate function applied to the column, only used in having expressions
+	Pattern    string
+	Escape     rune   // Character that makes the next character of the pattern match itself, 0 if there is none
+	Token      *Token // Token of the column in the query, nil if the filter was not parsed
+}
+
+// Check if a value matches the pattern of the filter, null values are unknown
+func (filter *LikeFilter) IsIncluded(value Value) Truth {
+	if value.IsNull() {
+		return TRUTH_UNKNOWN
+	}
+
+	return GetTruth(matchLike([]rune(value.String), []rune(filter.Pattern), filter.Escape))
+}
+
+// Evaluate the predicate for a row, predicates on unknown columns are always false
+func (filter *LikeFilter) Evaluate(table *Table, rowIndex int) Truth {
+	col, err := table.getColumnByName(filter.projection().Name())
+	if err != nil {
+		return TRUTH_FALSE
+	}
+
+	return filter.IsIncluded(col.Values[rowIndex])
+}
+
+// Get the column or aggregate function the predicate checks
+func (filter *LikeFilter) projection() *Projection {
+	return &Projection{ColumnName: filter.ColumnName, Function: filter.Function, Token: filter.Token}
+}
+
+// Match a string to a like pattern.
+// After a % the rest of the pattern is tried at every following character, the last % is the only one that is backtracked to
+func matchLike(s []rune, pattern []rune, escape rune) bool {
+	i, j := 0, 0
+	backtrackI, backtrackJ := -1, -1
+	for i < len(s) {
+		if j < len(pattern) {
+			switch c := pattern[j]; {
+			case escape != 0 && c == escape && j+1 < len(pattern):
+				if s[i] == pattern[j+1] {
+					i, j = i+1, j+2
+					continue
+				}
+			case c == '%':
+				backtrackI, backtrackJ = i, j+1
+				j++
+				continue
+			case c == '_' || c == s[i]:
+				i, j = i+1, j+1
+				continue
+			}
+		}
+
+		if backtrackJ < 0 {
+			return false
+		}
+
+		backtrackI++
+		i, j = backtrackI, backtrackJ
+	}
+
+	for j < len(pattern) && pattern[j] == '%' {
+		j++
+	}
+
+	return j == len(pattern)
+}
@@ -0,0 +1,290 @@
+package sql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"strconv"
+)
+
+// Bytes at the start of every table file
+const TABLE_FILE_MAGIC = "DSQL"
+
+// Version of the table file format, files of other versions cannot be read
+const TABLE_FILE_VERSION = 1
+
+// Extension of the table files
+const TABLE_FILE_EXTENSION = ".tbl"
+
+// Flags of the column constraints in a table file
+const (
+	flagPrimaryKey byte = 1
+	flagNotNull    byte = 2
+	flagUnique     byte = 4
+)
+
+// Encode a table to the binary table file format.
+//
+// The file starts with a header of the magic bytes, the format version, the table name, the row count and the columns.
+// Every column has a name, a type, the constraint flags and a default value.
+// The header is followed by the values of one column at a time, a bitmap of the null values first and then the values that are not null.
+// Integers are written as varints, decimal numbers as 8 bytes and strings as length-prefixed bytes.
+// The file ends with a crc32 checksum of the preceding bytes, fixed size numbers are written in little-endian byte order
+func encodeTable(table *Table) ([]byte, error) {
+	rowCount := table.getRowCount()
+	data := []byte(TABLE_FILE_MAGIC)
+	data = binary.AppendUvarint(data, TABLE_FILE_VERSION)
+	data = appendString(data, table.Name)
+	data = binary.AppendUvarint(data, uint64(rowCount))
+	data = binary.AppendUvarint(data, uint64(len(table.Columns)))
+	for _, col := range table.Columns {
+		flags := byte(0)
+		if col.PrimaryKey {
+			flags |= flagPrimaryKey
+		}
+
+		if col.NotNull {
+			flags |= flagNotNull
+		}
+
+		if col.Unique {
+			flags |= flagUnique
+		}
+
+		data = appendString(data, col.Name)
+		data = binary.AppendUvarint(data, uint64(col.Type))
+		data = append(data, flags)
+
+		var err error
+		data = append(data, boolToByte(col.Default.Valid))
+		if col.Default.Valid {
+			data, err = appendValue(data, col.Type, col.Default)
+			if err != nil {
+				return nil, fmt.Errorf("default value of column %s could not be encoded, %w", col.Name, err)
+			}
+		}
+	}
+
+	for _, col := range table.Columns {
+		if len(col.Values) != rowCount {
+			return nil, fmt.Errorf("column %s has %d values but the table has %d rows", col.Name, len(col.Values), rowCount)
+		}
+
+		nulls := make([]byte, (rowCount+7)/8)
+		for i, value := range col.Values {
+			if value.IsNull() {
+				nulls[i/8] |= 1 << (i % 8)
+			}
+		}
+
+		data = append(data, nulls...)
+		for _, value := range col.Values {
+			if value.IsNull() {
+				continue
+			}
+
+			var err error
+			data, err = appendValue(data, col.Type, value)
+			if err != nil {
+				return nil, fmt.Errorf("value of column %s could not be encoded, %w", col.Name, err)
+			}
+		}
+	}
+
+	return binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(data)), nil
+}
+
+// Decode a table from the binary table file format, see encodeTable
+func decodeTable(data []byte) (*Table, error) {
+	if len(data) < len(TABLE_FILE_MAGIC)+4 || !bytes.HasPrefix(data, []byte(TABLE_FILE_MAGIC)) {
+		return nil, fmt.Errorf("not a table file")
+	}
+
+	content := data[:len(data)-4]
+	if crc32.ChecksumIEEE(content) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return nil, fmt.Errorf("table file is corrupted, checksum does not match")
+	}
+
+	decoder := &decoder{data: content, offset: len(TABLE_FILE_MAGIC)}
+	version := decoder.uvarint()
+	if decoder.err == nil && version != TABLE_FILE_VERSION {
+		return nil, fmt.Errorf("unsupported table file version: %d", version)
+	}
+
+	table := &Table{Name: decoder.string(), Columns: []*Column{}}
+	rowCount := decoder.uvarint()
+	colCount := int(decoder.uvarint())
+	for i := 0; i < colCount && decoder.err == nil; i++ {
+		col := &Column{Name: decoder.string(), Type: ColumnType(decoder.uvarint())}
+		flags := decoder.byte()
+		col.PrimaryKey = flags&flagPrimaryKey != 0
+		col.NotNull = flags&flagNotNull != 0
+		col.Unique = flags&flagUnique != 0
+		if decoder.byte() != 0 {
+			col.Default = decoder.value(col.Type)
+		}
+
+		table.Columns = append(table.Columns, col)
+	}
+
+	// EVERY COLUMN HAS A NULL BIT FOR EACH ROW, SO THE ROWS MUST FIT IN THE REST OF THE FILE BEFORE THE VALUES ARE ALLOCATED
+	if decoder.err == nil && len(table.Columns) > 0 && rowCount > uint64(len(content)-decoder.offset)*8/uint64(len(table.Columns)) {
+		return nil, fmt.Errorf("table file is corrupted, %d rows do not fit in the file", rowCount)
+	}
+
+	for _, col := range table.Columns {
+		nulls := decoder.bytes(int(rowCount+7) / 8)
+		if decoder.err != nil {
+			break
+		}
+
+		col.Values = make([]Value, rowCount)
+		for i := range col.Values {
+			if nulls[i/8]&(1<<(i%8)) == 0 {
+				col.Values[i] = decoder.value(col.Type)
+			}
+		}
+	}
+
+	if decoder.err != nil {
+		return nil, decoder.err
+	}
+
+	if decoder.offset != len(content) {
+		return nil, fmt.Errorf("table file is corrupted, unexpected data after the values")
+	}
+
+	return table, nil
+}
+
+// Append a value that is not null as the type of its column
+func appendValue(data []byte, t ColumnType, value Value) ([]byte, error) {
+	switch t {
+	case TYPE_INT:
+		intValue, err := strconv.ParseInt(value.String, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a valid integer", value.String)
+		}
+
+		return binary.AppendVarint(data, intValue), nil
+	case TYPE_FLOAT:
+		floatValue, err := strconv.ParseFloat(value.String, 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a valid decimal number", value.String)
+		}
+
+		return binary.LittleEndian.AppendUint64(data, math.Float64bits(floatValue)), nil
+	case TYPE_VARCHAR:
+		return appendString(data, value.String), nil
+	}
+
+	return nil, fmt.Errorf("invalid column type: %s", t.ToString())
+}
+
+// Append a length-prefixed string
+func appendString(data []byte, s string) []byte {
+	return append(binary.AppendUvarint(data, uint64(len(s))), s...)
+}
+
+// Convert a boolean to a byte, 1 for true and 0 for false
+func boolToByte(b bool) byte {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// Reads values from the binary table file format.
+// The first error stops the reading, after which every read returns a zero value
+type decoder struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+// Read n bytes
+func (decoder *decoder) bytes(n int) []byte {
+	if decoder.err != nil {
+		return nil
+	}
+
+	if n < 0 || len(decoder.data)-decoder.offset < n {
+		decoder.err = fmt.Errorf("table file is corrupted, unexpected end of file")
+		return nil
+	}
+
+	b := decoder.data[decoder.offset : decoder.offset+n]
+	decoder.offset += n
+	return b
+}
+
+// Read a single byte
+func (decoder *decoder) byte() byte {
+	b := decoder.bytes(1)
+	if b == nil {
+		return 0
+	}
+
+	return b[0]
+}
+
+// Read an unsigned varint
+func (decoder *decoder) uvarint() uint64 {
+	if decoder.err != nil {
+		return 0
+	}
+
+	value, n := binary.Uvarint(decoder.data[decoder.offset:])
+	if n <= 0 {
+		decoder.err = fmt.Errorf("table file is corrupted, invalid number")
+		return 0
+	}
+
+	decoder.offset += n
+	return value
+}
+
+// Read a length-prefixed string
+func (decoder *decoder) string() string {
+	length := decoder.uvarint()
+	if length > uint64(len(decoder.data)) {
+		decoder.err = fmt.Errorf("table file is corrupted, unexpected end of file")
+		return ""
+	}
+
+	return string(decoder.bytes(int(length)))
+}
+
+// Read a value that is not null as the type of its column
+func (decoder *decoder) value(t ColumnType) Value {
+	if decoder.err != nil {
+		return Value{}
+	}
+
+	switch t {
+	case TYPE_INT:
+		value, n := binary.Varint(decoder.data[decoder.offset:])
+		if n <= 0 {
+			decoder.err = fmt.Errorf("table file is corrupted, invalid number")
+			return Value{}
+		}
+
+		decoder.offset += n
+		return NewValue(strconv.FormatInt(value, 10))
+	case TYPE_FLOAT:
+		b := decoder.bytes(8)
+		if b == nil {
+			return Value{}
+		}
+
+		return NewValue(FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(b))))
+	case TYPE_VARCHAR:
+		return NewValue(decoder.string())
+	}
+
+	decoder.err = fmt.Errorf("table file is corrupted, invalid column type: %d", t)
+	return Value{}
+}
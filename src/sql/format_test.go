@@ -0,0 +1,117 @@
+package sql
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatTable(t *testing.T) {
+	table := &Table{Name: "table", Columns: []*Column{
+		{Name: "col1", Type: TYPE_INT, PrimaryKey: true, Values: NewValues("1", "-20", "300000")},
+		{Name: "col2", Type: TYPE_FLOAT, Default: NewValue("0.5"), Values: []Value{NewValue("1.25"), {}, NewValue("-3")}},
+		{Name: "col3", Type: TYPE_VARCHAR, NotNull: true, Unique: true, Values: NewValues("a", "", "value with spaces")},
+	}}
+
+	data, err := encodeTable(table)
+	if err != nil {
+		t.Fatal("encode returned an error but should not have")
+	}
+
+	decoded, err := decodeTable(data)
+	if err != nil || !reflect.DeepEqual(table, decoded) {
+		t.Fatal("decoded table should be equal to the encoded table")
+	}
+
+	data[len(data)/2]++
+	_, err = decodeTable(data)
+	if err == nil {
+		t.Fatal("error was not thrown for a corrupted file but should have")
+	}
+}
+
+func TestFormatTableRowCount(t *testing.T) {
+	for _, rowCount := range []uint64{17, 1 << 40, math.MaxUint64} {
+		data := binary.AppendUvarint([]byte(TABLE_FILE_MAGIC), TABLE_FILE_VERSION)
+		data = appendString(data, "table")
+		data = binary.AppendUvarint(data, rowCount)
+		data = binary.AppendUvarint(data, 1)
+		data = appendString(data, "col1")
+		data = append(binary.AppendUvarint(data, uint64(TYPE_INT)), 0, 0, 0xFF)
+		data = binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+
+		_, err := decodeTable(data)
+		if err == nil {
+			t.Fatalf("error was not thrown for %d rows in a file of one null bitmap byte but should have", rowCount)
+		}
+	}
+}
+
+func TestFormatMigrate(t *testing.T) {
+	rootPath := t.TempDir()
+	data, _ := json.Marshal(&Table{Name: "table", Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: NewValues("1")}}})
+	os.WriteFile(filepath.Join(rootPath, "table.json"), data, fs.ModePerm)
+
+	database := NewDatabase(rootPath)
+	err := database.Load()
+	if err != nil {
+		t.Fatal("load returned an error but should not have")
+	}
+
+	_, err = os.Stat(filepath.Join(rootPath, "table.1"+TABLE_FILE_EXTENSION))
+	if err != nil {
+		t.Fatal("load should have written the json table as a table file")
+	}
+
+	_, err = os.Stat(filepath.Join(rootPath, "table.json"))
+	if err == nil {
+		t.Fatal("load should have removed the json table")
+	}
+}
+
+func TestFormatMigrateInvalidValues(t *testing.T) {
+	rootPath := t.TempDir()
+	data := []byte(`{"table": "table", "columns": [{"column": "col1", "type": 0, "default": "5", "values": ["1", null, "007"]}]}`)
+	os.WriteFile(filepath.Join(rootPath, "table.json"), data, fs.ModePerm)
+
+	database := NewDatabase(rootPath)
+	err := database.Load()
+	if err != nil {
+		t.Fatalf("load returned an error but should not have: %s", err.Error())
+	}
+
+	table, err := database.Get("table")
+	if err != nil || !reflect.DeepEqual(table.Columns[0].Values, []Value{NewValue("1"), {}, NewValue("7")}) || table.Columns[0].Default != NewValue("5") {
+		t.Fatal("values of the json table should have been converted to the type of the column")
+	}
+
+	for _, values := range []string{`"default": "x", "values": ["1"]`, `"values": ["1", "x"]`, `"values": ["1.0"]`} {
+		rootPath = t.TempDir()
+		filePath := filepath.Join(rootPath, "table.json")
+		os.WriteFile(filePath, []byte(`{"table": "table", "columns": [{"column": "col1", "type": 0, `+values+`}]}`), fs.ModePerm)
+		database = NewDatabase(rootPath)
+		err = database.Load()
+		if err == nil || len(database.tables) != 0 || !strings.Contains(err.Error(), "col1") {
+			t.Fatalf("load should have returned an error naming the column for %s, got %v", values, err)
+		}
+
+		if _, err := os.Stat(filePath); err != nil {
+			t.Fatal("load should have kept the json table when a value could not be read")
+		}
+	}
+
+	rootPath = t.TempDir()
+	os.WriteFile(filepath.Join(rootPath, "table.json"), []byte(`{"table": "table", "columns": [{"column": "col1", "type": 0, "values": ["1"]}, {"column": "col2", "type": 2, "values": []}]}`), fs.ModePerm)
+	database = NewDatabase(rootPath)
+	err = database.Load()
+	if err == nil || len(database.tables) != 0 || database.log != nil {
+		t.Fatal("load should have returned an error for columns of different lengths and left the database empty")
+	}
+}
@@ -0,0 +1,285 @@
+package sql
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Represents an ordered index of the values of columns in a table.
+// Indexes are used to find the rows compared by a filter without reading every row of the table
+type Index struct {
+	Name        string   `json:"name"`             // Index name, unique in the database
+	ColumnNames []string `json:"columns"`          // Indexed columns, the first column is used to find rows
+	Unique      bool     `json:"unique,omitempty"` // Rows cannot have the same values in the columns, rows with null values are allowed
+	tree        *btree   // Rows by the values of the columns, nil until the index is built
+}
+
+// Create a copy of the index definition without the rows
+func (index *Index) definition() *Index {
+	return &Index{Name: index.Name, ColumnNames: slices.Clone(index.ColumnNames), Unique: index.Unique}
+}
+
+// Add an index to the table and build it from the rows of the table
+func (table *Table) addIndex(index *Index) error {
+	if len(index.ColumnNames) == 0 {
+		return newError(ERROR_SYNTAX, "index must have at least one column: %s", index.Name)
+	}
+
+	columns, err := table.getColumns(index.ColumnNames)
+	if err != nil {
+		return err
+	}
+
+	index.ColumnNames = Map(columns, func(col *Column) string { return col.Name })
+	err = table.buildIndex(index)
+	if err != nil {
+		return err
+	}
+
+	table.Indexes = append(table.Indexes, index)
+	return nil
+}
+
+// Remove an index from the table, returns false if the table does not have the index
+func (table *Table) removeIndex(indexName string) bool {
+	length := len(table.Indexes)
+	table.Indexes = slices.DeleteFunc(table.Indexes, func(index *Index) bool { return index.Name == indexName })
+	return len(table.Indexes) != length
+}
+
+// Build an index from the rows of the table, fails if a unique index has the same values twice
+func (table *Table) buildIndex(index *Index) error {
+	columns, err := table.getColumns(index.ColumnNames)
+	if err != nil {
+		return err
+	}
+
+	index.tree = newBtree(func(a []Value, b []Value) int {
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := Compare(columns[i].Type, a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+
+		return 0
+	})
+
+	for rowIndex := 0; rowIndex < table.getRowCount(); rowIndex++ {
+		key := table.getIndexKey(index, rowIndex)
+		if index.Unique && !slices.ContainsFunc(key, Value.IsNull) && index.tree.find(key) != nil {
+			return newError(ERROR_CONSTRAINT, "constraint violation: duplicate value %s in unique index %s", formatIndexKey(key), index.Name)
+		}
+
+		index.tree.add(key, rowIndex)
+	}
+
+	return nil
+}
+
+// Build every index of the table again, used after the rows are reverted to values that were valid for the indexes
+func (table *Table) rebuildIndexes() {
+	for _, index := range table.Indexes {
+		table.buildIndex(index)
+	}
+}
+
+// Get the values of the indexed columns of a row
+func (table *Table) getIndexKey(index *Index, rowIndex int) []Value {
+	return Map(index.ColumnNames, func(colName string) Value {
+		col, _ := table.getColumnByName(colName)
+		return col.Values[rowIndex]
+	})
+}
+
+// Check that new rows would not break the unique indexes of the table.
+// Rows are given in the order of the columns of the table
+func (table *Table) checkIndexes(newRows [][]Value) error {
+	for _, index := range table.Indexes {
+		if !index.Unique {
+			continue
+		}
+
+		colIndexes := Map(index.ColumnNames, func(colName string) int {
+			return slices.IndexFunc(table.Columns, func(col *Column) bool { return col.Name == colName })
+		})
+
+		keys := map[string]bool{}
+		for _, row := range newRows {
+			key := Map(colIndexes, func(colIndex int) Value { return row[colIndex] })
+			if slices.ContainsFunc(key, Value.IsNull) {
+				continue
+			}
+
+			if index.tree.find(key) != nil || keys[getIndexKeyId(key)] {
+				return newError(ERROR_CONSTRAINT, "constraint violation: duplicate value %s in unique index %s", formatIndexKey(key), index.Name)
+			}
+
+			keys[getIndexKeyId(key)] = true
+		}
+	}
+
+	return nil
+}
+
+// Check that changing the values of rows would not break the unique indexes of the table.
+// The new values of each row are given in the order of the columns
+func (table *Table) checkIndexesOnUpdate(rowIndexes []int, columns []*Column, newValues [][]Value) error {
+	for _, index := range table.Indexes {
+		if !index.Unique || !IsTrueForAny(columns, func(col *Column) bool { return slices.Contains(index.ColumnNames, col.Name) }) {
+			continue
+		}
+
+		keys := map[string]bool{}
+		for i, rowIndex := range rowIndexes {
+			key := table.getIndexKey(index, rowIndex)
+			for colIndex, col := range columns {
+				if keyIndex := slices.Index(index.ColumnNames, col.Name); keyIndex != -1 {
+					key[keyIndex] = newValues[i][colIndex]
+				}
+			}
+
+			if slices.ContainsFunc(key, Value.IsNull) {
+				continue
+			}
+
+			item := index.tree.find(key)
+			if keys[getIndexKeyId(key)] || item != nil && slices.ContainsFunc(item.rows, func(row int) bool { return !slices.Contains(rowIndexes, row) }) {
+				return newError(ERROR_CONSTRAINT, "constraint violation: duplicate value %s in unique index %s", formatIndexKey(key), index.Name)
+			}
+
+			keys[getIndexKeyId(key)] = true
+		}
+	}
+
+	return nil
+}
+
+// Get the indexes that contain any of the columns
+func (table *Table) getIndexesOfColumns(columns []*Column) []*Index {
+	return slices.DeleteFunc(slices.Clone(table.Indexes), func(index *Index) bool {
+		return !IsTrueForAny(columns, func(col *Column) bool { return slices.Contains(index.ColumnNames, col.Name) })
+	})
+}
+
+// Get the rows a condition can include in ascending order.
+// An index is used if the condition compares the first column of an index to a value, otherwise every row is returned
+func (table *Table) getCandidateRows(condition Condition) []int {
+	for _, index := range table.Indexes {
+		rowIndexes, ok := table.findIndexRows(index, condition)
+		if ok {
+			slices.Sort(rowIndexes)
+			return rowIndexes
+		}
+	}
+
+	rowIndexes := make([]int, table.getRowCount())
+	for i := range rowIndexes {
+		rowIndexes[i] = i
+	}
+
+	return rowIndexes
+}
+
+// Find the rows in an index that can be included by the filters combined with and in the condition.
+// Returns false if no filter compares the first column of the index
+func (table *Table) findIndexRows(index *Index, condition Condition) ([]int, bool) {
+	lower, upper, ok := table.getIndexBounds(index, condition)
+	if !ok {
+		return nil, false
+	}
+
+	rowIndexes := []int{}
+	if lower == nil {
+		return rowIndexes, true
+	}
+
+	index.tree.ascend(lower, upper, func(item *btreeItem) bool {
+		rowIndexes = append(rowIndexes, item.rows...)
+		return true
+	})
+
+	return rowIndexes, true
+}
+
+// Get the bounds of the keys of an index that can be included by the filters combined with and in the condition.
+// Returns false if no filter compares the first column of the index, the lower bound is nil if no key can be included
+func (table *Table) getIndexBounds(index *Index, condition Condition) (*btreeBound, *btreeBound, bool) {
+	col, err := table.getColumnByName(index.ColumnNames[0])
+	if err != nil || index.tree == nil {
+		return nil, nil, false
+	}
+
+	lower := &btreeBound{key: []Value{{}}, inclusive: false} // NULL VALUES ARE NEVER INCLUDED BY A COMPARISON
+	var upper *btreeBound
+	isUsed := false
+	for _, filter := range getAndFilters(condition) {
+		filterCol, err := table.getColumnByName(filter.ColumnName)
+		if err != nil || filterCol != col || filter.Function != AGGREGATE_NONE {
+			continue
+		}
+
+		if !slices.Contains([]EqualityOperator{LESS, LESS_OR_EQUAL, EQUAL, GREATER, GREATER_OR_EQUAL}, filter.Operator) {
+			continue
+		}
+
+		if filter.CompareValue.IsNull() {
+			return nil, nil, true
+		}
+
+		bound := &btreeBound{key: []Value{filter.CompareValue}, inclusive: filter.Operator&EQUAL != 0}
+		if filter.Operator&LESS == 0 && isTighterBound(col.Type, bound, lower, 1) {
+			lower = bound
+		}
+
+		if filter.Operator&GREATER == 0 && (upper == nil || isTighterBound(col.Type, bound, upper, -1)) {
+			upper = bound
+		}
+
+		isUsed = true
+	}
+
+	return lower, upper, isUsed
+}
+
+// Check if a bound includes less keys than another bound, direction is 1 for lower bounds and -1 for upper bounds
+func isTighterBound(t ColumnType, bound *btreeBound, other *btreeBound, direction int) bool {
+	c := Compare(t, bound.key[0], other.key[0]) * direction
+	return c > 0 || c == 0 && !bound.inclusive
+}
+
+// Get the filters of a condition that must all be true for the condition to be true
+func getAndFilters(condition Condition) []*Filter {
+	filters := []*Filter{}
+	for _, c := range getAndConditions(condition) {
+		if filter, ok := c.(*Filter); ok {
+			filters = append(filters, filter)
+		}
+	}
+
+	return filters
+}
+
+// Split a condition to the conditions combined with and, a nil condition has no parts
+func getAndConditions(condition Condition) []Condition {
+	switch c := condition.(type) {
+	case nil:
+		return []Condition{}
+	case *LogicalCondition:
+		if c.Operator == LOGICAL_AND {
+			return append(getAndConditions(c.Left), getAndConditions(c.Right)...)
+		}
+	}
+
+	return []Condition{condition}
+}
+
+// Get a string that identifies an index key that has no null values
+func getIndexKeyId(key []Value) string {
+	return strings.Join(Map(key, func(value Value) string { return strconv.Quote(value.String) }), ",")
+}
+
+// Format the values of an index key for an error message
+func formatIndexKey(key []Value) string {
+	return strings.Join(Map(key, Value.ToString), ", ")
+}
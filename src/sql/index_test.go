@@ -0,0 +1,127 @@
+package sql
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestIndexGet(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "age", Type: TYPE_INT, Values: []Value{NewValue("50"), {}, NewValue("45"), NewValue("25"), NewValue("45")}}}}
+	err := table.addIndex(&Index{Name: "index", ColumnNames: []string{"age"}})
+	if err != nil {
+		t.Fatal("add index returned an error but should not have")
+	}
+
+	condition := &LogicalCondition{
+		Operator: LOGICAL_AND,
+		Left:     &Filter{ColumnName: "age", Operator: GREATER_OR_EQUAL, CompareValue: NewValue("40")},
+		Right:    &Filter{ColumnName: "age", Operator: LESS_OR_EQUAL, CompareValue: NewValue("49")},
+	}
+
+	rowIndexes, ok := table.findIndexRows(table.Indexes[0], condition)
+	if !ok || !reflect.DeepEqual(rowIndexes, []int{2, 4}) {
+		t.Fatal("index should have found the rows in the range")
+	}
+
+	rowIndexes, ok = table.findIndexRows(table.Indexes[0], &Filter{ColumnName: "age", Operator: LESS, CompareValue: NewValue("50")})
+	if !ok || len(rowIndexes) != 3 {
+		t.Fatal("index should not have found the null values")
+	}
+
+	table = &Table{Columns: []*Column{{Name: "name", Type: TYPE_VARCHAR, Values: NewValues("b", "abc", "c", "ab", "bc")}}}
+	table.addIndex(&Index{Name: "index", ColumnNames: []string{"name"}})
+	rowIndexes, ok = table.findIndexRows(table.Indexes[0], &Filter{ColumnName: "name", Operator: LESS, CompareValue: NewValue("bc")})
+	if !ok || !reflect.DeepEqual(rowIndexes, []int{3, 1, 0}) {
+		t.Fatalf("index should have found the text values in the range in order, got %v", rowIndexes)
+	}
+}
+
+func TestIndexUnique(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: []Value{NewValue("1"), {}}}}}
+	err := table.addIndex(&Index{Name: "index", ColumnNames: []string{"col1"}, Unique: true})
+	if err != nil {
+		t.Fatal("add index returned an error but should not have")
+	}
+
+	err = table.Insert([]RowData{{ColName: "col1", Value: NewValue("1")}})
+	if err == nil {
+		t.Fatal("error was not thrown for a duplicate value but should have")
+	}
+
+	err = table.Insert([]RowData{{ColName: "col1"}})
+	if err != nil {
+		t.Fatal("unique index should allow many null values")
+	}
+
+	err = table.Update([]*Assignment{{ColumnName: "col1", Expression: &LiteralExpression{Value: NewValue("1")}}}, &NullFilter{ColumnName: "col1"})
+	if err == nil || len(table.Indexes[0].tree.find(NewValues("1")).rows) != 1 {
+		t.Fatal("error was not thrown for a duplicate value but should have")
+	}
+}
+
+func TestIndexChanges(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	indexed := &Table{Name: "t", Columns: []*Column{{Name: "col1", Type: TYPE_INT}, {Name: "col2", Type: TYPE_INT}}}
+	indexed.addIndex(&Index{Name: "index", ColumnNames: []string{"col1", "col2"}})
+	scanned := indexed.clone()
+	scanned.Indexes = nil
+
+	for i := 0; i < 2000; i++ {
+		value := NewValue(strconv.Itoa(random.Intn(100)))
+		newValue := NewValue(strconv.Itoa(random.Intn(100)))
+		filter := &Filter{ColumnName: "col1", Operator: []EqualityOperator{LESS, LESS_OR_EQUAL, EQUAL, GREATER, GREATER_OR_EQUAL}[random.Intn(5)], CompareValue: value}
+		for _, table := range []*Table{indexed, scanned} {
+			switch i % 4 {
+			case 0, 1:
+				table.Insert([]RowData{{ColName: "col1", Value: value}, {ColName: "col2", Value: NewValue(strconv.Itoa(i))}})
+			case 2:
+				table.Update([]*Assignment{{ColumnName: "col1", Expression: &LiteralExpression{Value: newValue}}}, &Filter{ColumnName: "col2", Operator: EQUAL, CompareValue: NewValue(strconv.Itoa(i - 1))})
+			case 3:
+				table.Delete(&Filter{ColumnName: "col1", Operator: EQUAL, CompareValue: value})
+			}
+		}
+
+		operation := &SelectOperation{TableName: "t", Projections: []*Projection{{ColumnName: "col1"}, {ColumnName: "col2"}}, Condition: filter}
+		a, _ := operation.getData(NewDatabase("", indexed))
+		b, _ := operation.getData(NewDatabase("", scanned))
+		if !reflect.DeepEqual(a, b) {
+			t.Fatalf("indexed table returned different rows than the scanned table after %d changes", i)
+		}
+	}
+}
+
+func TestIndexLoad(t *testing.T) {
+	rootPath := t.TempDir()
+	database := NewDatabase(rootPath)
+	database.Load()
+	for _, query := range []string{"CREATE TABLE items (col1 INT)", "INSERT INTO items VALUES (1), (2)", "CREATE UNIQUE INDEX index1 ON items (col1)", "CREATE INDEX index2 ON items (col1)", "DROP INDEX index2"} {
+		operation, err := Parse(Tokenize([]byte(query)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = operation.Call(database)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, save := range []bool{false, true} {
+		if save {
+			database.Save()
+		}
+
+		loaded := NewDatabase(rootPath)
+		err := loaded.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		table, _ := loaded.Get("items")
+		if len(table.Indexes) != 1 || table.Indexes[0].tree.find(NewValues("2")) == nil {
+			t.Fatal("load should have built the index of the table")
+		}
+	}
+}
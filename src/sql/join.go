@@ -0,0 +1,155 @@
+package sql
+
+import (
+	"fmt"
+)
+
+// Enum to represent a type of a join, values are prefixed with JOIN
+type JoinType int
+
+const (
+	JOIN_INNER JoinType = iota // Include only rows that have a match in both tables
+	JOIN_LEFT                  // Include every row of the left table, unmatched right columns are null
+)
+
+// Get a string value of a join type
+func (joinType JoinType) ToString() string {
+	switch joinType {
+	case JOIN_INNER:
+		return "INNER"
+	case JOIN_LEFT:
+		return "LEFT"
+	}
+
+	return ""
+}
+
+// Represents a table in the from clause
+type TableReference struct {
+	TableName string // Name of the table in the database
+	Alias     string // Name used to qualify the columns of the table, empty if not given
+}
+
+// Get the name used to qualify the columns of the table, alias or the table name
+func (reference *TableReference) Name() string {
+	if reference.Alias != "" {
+		return reference.Alias
+	}
+
+	return reference.TableName
+}
+
+// Represents a single join in the from clause
+type Join struct {
+	Type      JoinType
+	Table     TableReference
+	Condition Condition
+}
+
+// Represents a comparison between two columns of the same row, for example a.id = b.a_id
+type ColumnComparison struct {
+	LeftColumn  string
+	Operator    EqualityOperator
+	RightColumn string
+	LeftToken   *Token // Token of the left column in the query, nil if the comparison was not parsed
+	RightToken  *Token // Token of the right column in the query, nil if the comparison was not parsed
+}
+
+// Evaluate the comparison for a row, values are compared by the common type of the columns.
+// Columns that cannot be compared with each other are never equal, see getComparisonType
+func (comparison *ColumnComparison) Evaluate(table *Table, rowIndex int) Truth {
+	left, err := table.getColumnByName(comparison.LeftColumn)
+	if err != nil {
+		return TRUTH_FALSE
+	}
+
+	right, err := table.getColumnByName(comparison.RightColumn)
+	if err != nil {
+		return TRUTH_FALSE
+	}
+
+	t, err := getComparisonType(left, right)
+	if err != nil {
+		return TRUTH_FALSE
+	}
+
+	return comparison.Operator.Compare(t, left.Values[rowIndex], right.Values[rowIndex])
+}
+
+// Get the type two columns are compared by.
+// Integers and decimal numbers are compared as decimal numbers, text cannot be compared to numbers
+func getComparisonType(left *Column, right *Column) (ColumnType, error) {
+	if left.Type == right.Type {
+		return left.Type, nil
+	}
+
+	if left.Type.IsNumeric() && right.Type.IsNumeric() {
+		return TYPE_FLOAT, nil
+	}
+
+	return -1, newError(ERROR_TYPE, "column %s of type %s cannot be compared to column %s of type %s", left.Name, left.Type.ToString(), right.Name, right.Type.ToString())
+}
+
+// Create a table of which columns are qualified with a name, for example table.column.
+// The values and the indexes are shared with the original table
+func (table *Table) qualify(name string) *Table {
+	return &Table{
+		Columns: Map(table.Columns, func(col *Column) *Column {
+			return &Column{Name: fmt.Sprintf("%s.%s", name, col.Name), Type: col.Type, Values: col.Values}
+		}),
+		Indexes: table.Indexes,
+	}
+}
+
+// Join two tables to a new table that contains the columns of both tables.
+// Every row pair of the tables is included for which the condition is true
+func (table *Table) Join(other *Table, joinType JoinType, condition Condition) *Table {
+	columns := append(table.Columns[:len(table.Columns):len(table.Columns)], other.Columns...)
+	result := &Table{}
+	row := &Table{}
+	for _, col := range columns {
+		result.Columns = append(result.Columns, &Column{Name: col.Name, Type: col.Type, Values: []Value{}})
+		row.Columns = append(row.Columns, &Column{Name: col.Name, Type: col.Type, Values: []Value{{}}})
+	}
+
+	leftCount := table.getRowCount()
+	rightCount := other.getRowCount()
+	colCount := len(table.Columns)
+
+	for leftIndex := 0; leftIndex < leftCount; leftIndex++ {
+		for colIndex, col := range table.Columns {
+			row.Columns[colIndex].Values[0] = col.Values[leftIndex]
+		}
+
+		isMatched := false
+		for rightIndex := 0; rightIndex < rightCount; rightIndex++ {
+			for colIndex, col := range other.Columns {
+				row.Columns[colCount+colIndex].Values[0] = col.Values[rightIndex]
+			}
+
+			if !row.isRowIncluded(0, condition) {
+				continue
+			}
+
+			isMatched = true
+			result.appendRow(row, 0)
+		}
+
+		if !isMatched && joinType == JOIN_LEFT {
+			for colIndex := range other.Columns {
+				row.Columns[colCount+colIndex].Values[0] = Value{}
+			}
+
+			result.appendRow(row, 0)
+		}
+	}
+
+	return result
+}
+
+// Append a row of another table with the same columns to the table
+func (table *Table) appendRow(other *Table, rowIndex int) {
+	for colIndex, col := range table.Columns {
+		col.Values = append(col.Values, other.Columns[colIndex].Values[rowIndex])
+	}
+}
@@ -0,0 +1,74 @@
+package sql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTableJoin(t *testing.T) {
+	artists := &Table{Columns: []*Column{
+		{Name: "id", Type: TYPE_INT, Values: NewValues("1", "2", "3")},
+		{Name: "name", Type: TYPE_VARCHAR, Values: NewValues("x", "y", "z")},
+	}}
+	albums := &Table{Columns: []*Column{
+		{Name: "artist_id", Type: TYPE_INT, Values: NewValues("1", "1", "2")},
+		{Name: "title", Type: TYPE_VARCHAR, Values: NewValues("t1", "t2", "t3")},
+	}}
+
+	condition := &ColumnComparison{LeftColumn: "a.id", Operator: EQUAL, RightColumn: "b.artist_id"}
+	inner := artists.qualify("a").Join(albums.qualify("b"), JOIN_INNER, condition)
+	if inner.getRowCount() != 3 {
+		t.Fatalf("wrong number of rows in inner join, expected=3, got=%d", inner.getRowCount())
+	}
+
+	left := artists.qualify("a").Join(albums.qualify("b"), JOIN_LEFT, condition)
+	if left.getRowCount() != 4 {
+		t.Fatalf("wrong number of rows in left join, expected=4, got=%d", left.getRowCount())
+	}
+
+	col, err := left.getColumnByName("title")
+	if err != nil || !col.Values[3].IsNull() {
+		t.Fatal("unmatched row of left join should be null")
+	}
+}
+
+func TestTableColumnAmbiguous(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "a.id", Type: TYPE_INT}, {Name: "b.id", Type: TYPE_INT}}}
+	_, err := table.getColumnByName("id")
+	if err == nil {
+		t.Fatal("error was not thrown but should have")
+	}
+
+	_, err = table.getColumnByName("b.id")
+	if err != nil {
+		t.Fatal("qualified column should be found but was not")
+	}
+}
+
+func TestSelectJoinColumnNames(t *testing.T) {
+	database := NewDatabase("",
+		&Table{Name: "artists", Columns: []*Column{{Name: "id", Type: TYPE_INT, Values: NewValues("1")}, {Name: "name", Type: TYPE_VARCHAR, Values: NewValues("x")}}},
+		&Table{Name: "albums", Columns: []*Column{{Name: "artist_id", Type: TYPE_INT, Values: NewValues("1")}, {Name: "title", Type: TYPE_VARCHAR, Values: NewValues("t1")}}},
+	)
+
+	tests := []struct {
+		query   string
+		columns []string
+	}{
+		{"SELECT name, b.title FROM artists a JOIN albums b ON a.id = b.artist_id", []string{"name", "b.title"}},
+		{"SELECT title, COUNT(*) FROM artists a JOIN albums b ON id = artist_id GROUP BY title", []string{"title", "COUNT(*)"}},
+		{"SELECT * FROM artists a JOIN albums b ON a.id = b.artist_id", []string{"a.id", "a.name", "b.artist_id", "b.title"}},
+	}
+
+	for _, test := range tests {
+		operation, err := Parse(Tokenize([]byte(test.query)))
+		if err != nil {
+			t.Fatalf("parse returned an error but should not have: %s", err.Error())
+		}
+
+		data, err := operation.(*SelectOperation).getData(database)
+		if err != nil || !reflect.DeepEqual(data.Columns, test.columns) || len(data.Data) != 1 {
+			t.Fatalf("wrong columns for %s, expected %v, got %v", test.query, test.columns, data)
+		}
+	}
+}
@@ -1,139 +1,288 @@
-package sql
-
-// A single token
-type Token struct {
-	Type  TokenType
-	Value string
-}
-
-// Enum to represent a type of a token, values are named with a TOKEN prefix
-type TokenType int
-
-const (
-	// Token represents text
-	TOKEN_TEXT TokenType = iota
-	// Token represents a single operator `< > =`
-	TOKEN_OPERATOR
-	// Token represents a single comma `,`
-	TOKEN_COMMA
-	// Token represents a single asterisk `*`
-	TOKEN_ASTERISK
-	// Token represents a single parenthesis `( )`
-	TOKEN_PARENTHESIS
-)
-
-// Get a TokenType enum value based of the input string
-func GetTokenType(value string) TokenType {
-	switch value {
-	case "*":
-		return TOKEN_ASTERISK
-	case ",":
-		return TOKEN_COMMA
-	case "=", "<", ">":
-		return TOKEN_OPERATOR
-	case "(", ")":
-		return TOKEN_PARENTHESIS
-	default:
-		return TOKEN_TEXT
-	}
-}
-
-// Read all tokens from an input byte array
-func Tokenize(b []byte) []*Token {
-	start := 0
-	tokens := []*Token{}
-	isQuoted := false
-
-	for i := 0; i < len(b); i++ {
-		if isQuoted {
-			if b[i] != '\'' {
-				continue
-			}
-
-			value := string(b[start+1 : i])
-			token := &Token{
-				Type:  GetTokenType(value),
-				Value: value,
-			}
-			tokens = append(tokens, token)
-			start = i + 1
-			isQuoted = false
-			continue
-		}
-
-		if b[i] == '\'' {
-			if start <= i-1 {
-				value := string(b[start:i])
-				token := &Token{
-					Type:  GetTokenType(value),
-					Value: value,
-				}
-				tokens = append(tokens, token)
-				start = i
-			}
-
-			isQuoted = true
-			continue
-		}
-
-		if IsSpecial(b[i]) {
-			if start <= i-1 {
-				value := string(b[start:i])
-				token := &Token{
-					Type:  GetTokenType(value),
-					Value: value,
-				}
-				tokens = append(tokens, token)
-				start = i
-			}
-
-			value := string(b[start : i+1])
-			token := &Token{
-				Type:  GetTokenType(value),
-				Value: value,
-			}
-			tokens = append(tokens, token)
-			start = i + 1
-			continue
-		}
-
-		if IsAlphaNumeric(b[i]) {
-			if i == len(b)-1 {
-				value := string(b[start : i+1])
-				token := &Token{
-					Type:  GetTokenType(value),
-					Value: value,
-				}
-				tokens = append(tokens, token)
-				break
-			}
-
-			continue
-		}
-
-		// End of token
-		if start != i {
-			value := string(b[start:i])
-			token := &Token{
-				Type:  GetTokenType(value),
-				Value: value,
-			}
-			tokens = append(tokens, token)
-		}
-
-		start = i + 1
-	}
-
-	return tokens
-}
-
-// Check if a character is in the alphabet or a number ([a-z] or [0-9])
-func IsAlphaNumeric(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
-}
-
-// Check if a character is a special character in the sql syntax.
-// `= < > ( ) * ,`
-func IsSpecial(c byte) bool {
-	return c == '=' || c == '<' || c == '>' || c == '(' || c == ')' || c == '*' || c == ','
-}
+package sql
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A single token
+type Token struct {
+	Type   TokenType
+	Value  string
+	Line   int    // Line of the first character of the token, starting from 1
+	Column int    // Column of the first character of the token in characters, starting from 1
+	Offset int    // Byte offset of the first byte of the token in the input
+	End    int    // Byte offset right after the last byte of the token in the input, quotes of a quoted value are included
+	Index  int    // Index of the token in the tokens of the input, starting from 0
+	source []byte // Input the token was read from, used to show the token in errors
+}
+
+// Enum to represent a type of a token, values are named with a TOKEN prefix
+type TokenType int
+
+const (
+	// Token represents a reserved word of the sql syntax such as `SELECT`
+	TOKEN_KEYWORD TokenType = iota
+	// Token represents a name of a table, a column or an index, names in double quotes are always identifiers
+	TOKEN_IDENTIFIER
+	// Token represents a value in single quotes
+	TOKEN_STRING_LITERAL
+	// Token represents an integer or a decimal number
+	TOKEN_NUMBER_LITERAL
+	// Token represents a comparison operator `< > = <= >= <> !=`
+	TOKEN_OPERATOR
+	// Token represents a single comma `,`
+	TOKEN_COMMA
+	// Token represents a single asterisk `*`
+	TOKEN_ASTERISK
+	// Token represents a single parenthesis `( )`
+	TOKEN_PARENTHESIS
+	// Token represents a single arithmetic operator `+ - / %`
+	TOKEN_ARITHMETIC
+	// Token represents a single semicolon `;` that ends a statement
+	TOKEN_SEMICOLON
+	// Token represents a character that is not a part of the sql syntax
+	TOKEN_INVALID
+)
+
+// Get a TokenType enum value based of the input string, quoted values are not recognized
+func GetTokenType(value string) TokenType {
+	switch value {
+	case "*":
+		return TOKEN_ASTERISK
+	case ",":
+		return TOKEN_COMMA
+	case "=", "<", ">", "<=", ">=", "<>", "!=":
+		return TOKEN_OPERATOR
+	case "(", ")":
+		return TOKEN_PARENTHESIS
+	case "+", "-", "/", "%":
+		return TOKEN_ARITHMETIC
+	case ";":
+		return TOKEN_SEMICOLON
+	}
+
+	switch {
+	case IsKeyword(value):
+		return TOKEN_KEYWORD
+	case IsNumber(value):
+		return TOKEN_NUMBER_LITERAL
+	case value != "" && IsWordCharacter(value[0]):
+		return TOKEN_IDENTIFIER
+	}
+
+	return TOKEN_INVALID
+}
+
+// Check if a word is a reserved word of the sql syntax (not casesensitive).
+// Type names and aggregate functions are not reserved, so they can be used as names
+func IsKeyword(s string) bool {
+	switch strings.ToUpper(s) {
+	case "SELECT", "FROM", "WHERE", "AND", "OR", "NOT", "IS", "NULL", "AS", "IN", "BETWEEN", "LIKE", "ESCAPE",
+		"JOIN", "INNER", "LEFT", "OUTER", "ON", "GROUP", "BY", "HAVING", "ORDER", "ASC", "DESC", "LIMIT", "OFFSET",
+		"INSERT", "INTO", "VALUES", "UPDATE", "SET", "DELETE", "CREATE", "DROP", "TABLE", "INDEX",
+		"PRIMARY", "KEY", "UNIQUE", "DEFAULT", "BEGIN", "START", "TRANSACTION", "COMMIT", "ROLLBACK", "EXPLAIN":
+		return true
+	}
+
+	return false
+}
+
+// Check if a word is an integer or a decimal number, for example 1, -2.5 or .5
+func IsNumber(s string) bool {
+	digits := strings.TrimPrefix(s, "-")
+	if digits == "" || (digits[0] < '0' || digits[0] > '9') && digits[0] != '.' {
+		return false
+	}
+
+	_, err := strconv.ParseFloat(digits, 64)
+	return err == nil
+}
+
+// Read all tokens from an input byte array.
+//
+// Whitespace, `-- line comments` and `/* block comments */` separate tokens and are skipped.
+// Values in single quotes and identifiers in double quotes are read as a single token without the quotes,
+// a quote inside the quotes is escaped by writing it twice.
+// A quoted value or a block comment that is not closed is read to the end of the input as an invalid token.
+// A minus sign is read as a part of a number when it cannot be a subtraction, for example in `x > -1` but not in `x -1`.
+// Comparison operators of two characters such as `<=` are read as a single token
+func Tokenize(b []byte) []*Token {
+	lexer := &lexer{input: b, line: 1, tokens: []*Token{}}
+	for lexer.offset < len(b) {
+		c := b[lexer.offset]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			lexer.advance(1)
+		case lexer.hasPrefix("--"):
+			lexer.skipUntil("\n")
+		case lexer.hasPrefix("/*"):
+			lexer.skipComment()
+		case c == '\'':
+			lexer.readQuoted(c, TOKEN_STRING_LITERAL)
+		case c == '"':
+			lexer.readQuoted(c, TOKEN_IDENTIFIER)
+		case lexer.isNegativeNumber():
+			lexer.read(1 + lexer.countWordCharacters(lexer.offset+1))
+		case IsWordCharacter(c):
+			lexer.read(lexer.countWordCharacters(lexer.offset))
+		case lexer.hasPrefix("<=") || lexer.hasPrefix(">=") || lexer.hasPrefix("<>") || lexer.hasPrefix("!="):
+			lexer.read(2)
+		default:
+			lexer.read(1)
+		}
+	}
+
+	return lexer.tokens
+}
+
+// Reads tokens from an input, keeps track of the line of the read position
+type lexer struct {
+	input     []byte
+	offset    int // Byte offset of the read position
+	line      int // Line of the read position, starting from 1
+	lineStart int // Byte offset of the start of the line
+	tokens    []*Token
+}
+
+// Move the read position forward by a number of bytes, the end of the input is not passed
+func (lexer *lexer) advance(n int) {
+	end := min(lexer.offset+n, len(lexer.input))
+	for ; lexer.offset < end; lexer.offset++ {
+		if lexer.input[lexer.offset] == '\n' {
+			lexer.line++
+			lexer.lineStart = lexer.offset + 1
+		}
+	}
+}
+
+// Check if the input continues with a string at the read position
+func (lexer *lexer) hasPrefix(s string) bool {
+	return bytes.HasPrefix(lexer.input[lexer.offset:], []byte(s))
+}
+
+// Move the read position to the start of a string or to the end of the input
+func (lexer *lexer) skipUntil(s string) {
+	n := bytes.Index(lexer.input[lexer.offset:], []byte(s))
+	if n == -1 {
+		n = len(lexer.input) - lexer.offset
+	}
+
+	lexer.advance(n)
+}
+
+// Skip a block comment, a comment that is not closed is read as an invalid token
+func (lexer *lexer) skipComment() {
+	n := bytes.Index(lexer.input[lexer.offset+2:], []byte("*/"))
+	if n == -1 {
+		lexer.read(len(lexer.input) - lexer.offset)
+		lexer.tokens[len(lexer.tokens)-1].Type = TOKEN_INVALID
+		return
+	}
+
+	lexer.advance(n + 4)
+}
+
+// Create a token at the read position with a value
+func (lexer *lexer) newToken(value string, t TokenType) *Token {
+	return &Token{
+		Type:   t,
+		Value:  value,
+		Line:   lexer.line,
+		Column: utf8.RuneCount(lexer.input[lexer.lineStart:lexer.offset]) + 1,
+		Offset: lexer.offset,
+		Index:  len(lexer.tokens),
+		source: lexer.input,
+	}
+}
+
+// Read a number of bytes as a token, the type is based on the value
+func (lexer *lexer) read(n int) {
+	value := string(lexer.input[lexer.offset : lexer.offset+n])
+	token := lexer.newToken(value, GetTokenType(value))
+	lexer.advance(n)
+	token.End = lexer.offset
+	lexer.tokens = append(lexer.tokens, token)
+}
+
+// Read a value in quotes as a token of a type, two quotes in a row are read as a single quote.
+// A value without the closing quote is an invalid token of the rest of the input, including the opening quote
+func (lexer *lexer) readQuoted(quote byte, t TokenType) {
+	token := lexer.newToken("", t)
+	value := []byte{}
+	isClosed := false
+	lexer.advance(1)
+	for lexer.offset < len(lexer.input) && !isClosed {
+		c := lexer.input[lexer.offset]
+		lexer.advance(1)
+		if c != quote {
+			value = append(value, c)
+			continue
+		}
+
+		if lexer.offset >= len(lexer.input) || lexer.input[lexer.offset] != quote {
+			isClosed = true
+			continue
+		}
+
+		value = append(value, quote)
+		lexer.advance(1)
+	}
+
+	token.Value, token.End = string(value), lexer.offset
+	if !isClosed {
+		token.Type, token.Value = TOKEN_INVALID, string(lexer.input[token.Offset:])
+	}
+
+	lexer.tokens = append(lexer.tokens, token)
+}
+
+// Count the word characters starting from an offset
+func (lexer *lexer) countWordCharacters(offset int) int {
+	n := 0
+	for offset+n < len(lexer.input) && IsWordCharacter(lexer.input[offset+n]) {
+		n++
+	}
+
+	return n
+}
+
+// Check if a minus sign at the read position starts a negative number.
+// The minus sign is a subtraction if it follows a name, a value or a closing parenthesis
+func (lexer *lexer) isNegativeNumber() bool {
+	if lexer.input[lexer.offset] != '-' || lexer.offset+1 >= len(lexer.input) {
+		return false
+	}
+
+	next := lexer.input[lexer.offset+1]
+	if (next < '0' || next > '9') && next != '.' {
+		return false
+	}
+
+	if len(lexer.tokens) == 0 {
+		return true
+	}
+
+	previous := lexer.tokens[len(lexer.tokens)-1]
+	switch previous.Type {
+	case TOKEN_IDENTIFIER, TOKEN_STRING_LITERAL, TOKEN_NUMBER_LITERAL:
+		return false
+	case TOKEN_KEYWORD:
+		return strings.ToUpper(previous.Value) != "NULL"
+	}
+
+	return previous.Value != ")"
+}
+
+// Check if a character is in the alphabet or a number ([a-z] or [0-9])
+func IsAlphaNumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// Check if a character can be a part of a word, alphanumeric characters, underscore, bytes of non-ascii characters and
+// a dot that separates the table from the column in qualified names such as table.column
+func IsWordCharacter(c byte) bool {
+	return IsAlphaNumeric(c) || c == '_' || c == '.' || c >= utf8.RuneSelf
+}
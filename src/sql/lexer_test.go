@@ -0,0 +1,87 @@
+package sql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenizePosition(t *testing.T) {
+	tokens := Tokenize([]byte("SELECT *\nFROM 'ä''' x 'y"))
+	expected := [][4]int{{1, 1, 0, 6}, {1, 8, 7, 8}, {2, 1, 9, 13}, {2, 6, 14, 20}, {2, 12, 21, 22}, {2, 14, 23, 25}}
+	if len(tokens) != len(expected) {
+		t.Fatalf("wrong number of tokens, expected=%d, got=%d", len(expected), len(tokens))
+	}
+
+	for i, token := range tokens {
+		position := [4]int{token.Line, token.Column, token.Offset, token.End}
+		if position != expected[i] {
+			t.Fatalf("wrong position of token %s, expected=%v, got=%v", token.Value, expected[i], position)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input  string
+		values []string
+	}{
+		{"SELECT * -- comment\nFROM t /* block\ncomment */ WHERE a=1", []string{"SELECT", "*", "FROM", "t", "WHERE", "a", "=", "1"}},
+		{"'it''s' \"my column\" 'a;b'", []string{"it's", "my column", "a;b"}},
+		{"first_name=-1.5 AND x-1 > -.5;", []string{"first_name", "=", "-1.5", "AND", "x", "-", "1", ">", "-.5", ";"}},
+		{"a<=b>=c<>d!=e<f", []string{"a", "<=", "b", ">=", "c", "<>", "d", "!=", "e", "<", "f"}},
+		{"(1)-2, -3", []string{"(", "1", ")", "-", "2", ",", "-3"}},
+	}
+
+	for _, test := range tests {
+		tokens := Tokenize([]byte(test.input))
+		values := Map(tokens, func(token *Token) string { return token.Value })
+		if !reflect.DeepEqual(values, test.values) {
+			t.Fatalf("wrong tokens for %s, expected=%q, got=%q", test.input, test.values, values)
+		}
+	}
+
+	tokens := Tokenize([]byte("a <> 'b'"))
+	if tokens[1].Type != TOKEN_OPERATOR || GetEqualityOperator(tokens[1].Value) != NOT_EQUAL {
+		t.Fatal("wrong token types")
+	}
+}
+
+func TestTokenizeUnclosed(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int
+	}{
+		{"SELECT * FROM t WHERE name = 'abc", 29},
+		{"UPDATE t SET name = 'x; DELETE FROM t", 20},
+		{"SELECT \"name FROM t", 7},
+		{"SELECT * FROM t /* comment", 16},
+		{"SELECT 'it''s", 7},
+	}
+
+	for _, test := range tests {
+		tokens := Tokenize([]byte(test.input))
+		last := tokens[len(tokens)-1]
+		if last.Type != TOKEN_INVALID || last.Offset != test.offset || last.Value != test.input[test.offset:] {
+			t.Fatalf("unclosed value should be an invalid token of the rest of %s, got %+v", test.input, last)
+		}
+
+		_, err := ParseScript(tokens)
+		if err == nil || GetError(err).Category != ERROR_SYNTAX || GetError(err).Offset != test.offset {
+			t.Fatalf("parse should have returned a syntax error at offset %d for %s, got %v", test.offset, test.input, err)
+		}
+	}
+}
+
+func TestTokenType(t *testing.T) {
+	tokens := Tokenize([]byte(`select "from", name, 'x', -1.5, 2 FROM t WHERE a - -1 > 0 @`))
+	expected := []TokenType{
+		TOKEN_KEYWORD, TOKEN_IDENTIFIER, TOKEN_COMMA, TOKEN_IDENTIFIER, TOKEN_COMMA, TOKEN_STRING_LITERAL, TOKEN_COMMA,
+		TOKEN_NUMBER_LITERAL, TOKEN_COMMA, TOKEN_NUMBER_LITERAL, TOKEN_KEYWORD, TOKEN_IDENTIFIER, TOKEN_KEYWORD,
+		TOKEN_IDENTIFIER, TOKEN_ARITHMETIC, TOKEN_NUMBER_LITERAL, TOKEN_OPERATOR, TOKEN_NUMBER_LITERAL, TOKEN_INVALID,
+	}
+
+	types := Map(tokens, func(token *Token) TokenType { return token.Type })
+	if !reflect.DeepEqual(types, expected) {
+		t.Fatalf("wrong token types, expected=%v, got=%v", expected, types)
+	}
+}
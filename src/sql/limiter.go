@@ -0,0 +1,12 @@
+package sql
+
+// Represents a limit and an offset of a select, limits the number of rows returned
+type Limiter struct {
+	Limit  int // Maximum number of rows to return, -1 for no limit
+	Offset int // Number of rows to skip before returning rows
+}
+
+// Check if a number of rows already fills the limiter, so no more rows are needed
+func (limiter *Limiter) IsFull(rowCount int) bool {
+	return limiter != nil && limiter.Limit >= 0 && rowCount >= limiter.Offset+limiter.Limit
+}
@@ -1,138 +1,306 @@
-package sql
-
-import (
-	"encoding/json"
-)
-
-// Base contract of an sql operation
-type Operation interface {
-	Call(database *Database) ([]byte, error)
-}
-
-// Sql create operation, for creating new tables in the database
-type CreateOperation struct {
-	TableName string
-	Data      []ColData
-}
-
-// Create operation execute method, creates a new table with table_name in the database with the data in the operation
-func (operation *CreateOperation) Call(database *Database) ([]byte, error) {
-	err := database.Create(operation.TableName, operation.Data)
-	if err != nil {
-		return nil, err
-	}
-
-	err = database.Save()
-	return nil, err
-}
-
-// Sql insert operation, for inserting data to existing tables
-type InsertOperation struct {
-	TableName string
-	Data      []RowData
-}
-
-// Insert operation execute method, inserts data in the operation to a table by the table_name in the operation
-func (operation *InsertOperation) Call(database *Database) ([]byte, error) {
-	table, err := database.Get(operation.TableName)
-	if err != nil {
-		return nil, err
-	}
-
-	err = table.Insert(operation.Data)
-	if err != nil {
-		return nil, err
-	}
-
-	err = database.Save()
-	return nil, err
-}
-
-// Sql select operation, for fetching data from the database
-type SelectOperation struct {
-	TableName   string
-	ColumnNames []string
-	Filters     []*Filter
-	Sorters     []*Sorter
-}
-
-// Select operation execute method, fetches data from a table by table_name
-func (operation *SelectOperation) Call(database *Database) ([]byte, error) {
-	table, err := database.Get(operation.TableName)
-	if err != nil {
-		return nil, err
-	}
-
-	data, err := table.Get(operation.ColumnNames, operation.Filters, operation.Sorters)
-	if err != nil {
-		return nil, err
-	}
-
-	bytes, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-
-	return bytes, nil
-}
-
-// Sql update operation, for updating values in existing tables
-type UpdateOperation struct {
-	TableName string
-	Data      []RowData
-	Filters   []*Filter
-}
-
-// Update operation execute method, updates row of a table by table_name
-func (operation *UpdateOperation) Call(database *Database) ([]byte, error) {
-	table, err := database.Get(operation.TableName)
-	if err != nil {
-		return nil, err
-	}
-
-	err = table.Update(operation.Data, operation.Filters)
-	if err != nil {
-		return nil, err
-	}
-
-	err = database.Save()
-	return nil, err
-}
-
-// Sql delete operation, for deleting rows in existing tables
-type DeleteOperation struct {
-	TableName string
-	Filters   []*Filter
-}
-
-// Delete operation execute method, deletes rows included in the filters from a table by table_name
-func (operation *DeleteOperation) Call(database *Database) ([]byte, error) {
-	table, err := database.Get(operation.TableName)
-	if err != nil {
-		return nil, err
-	}
-
-	err = table.Delete(operation.Filters)
-	if err != nil {
-		return nil, err
-	}
-
-	err = database.Save()
-	return nil, err
-}
-
-// Sql drop operation, for deleting tables from the database
-type DropOperation struct {
-	TableName string
-}
-
-// Drop operation execute method, deletes the table from the database
-func (operation *DropOperation) Call(database *Database) ([]byte, error) {
-	err := database.Delete(operation.TableName)
-	if err != nil {
-		return nil, err
-	}
-
-	err = database.Save()
-	return nil, err
-}
+package sql
+
+import (
+	"encoding/json"
+)
+
+// Base contract of an sql operation
+type Operation interface {
+	Call(database *Database) ([]byte, error)
+}
+
+// Sql create operation, for creating new tables in the database
+type CreateOperation struct {
+	TableName string
+	Data      []ColData
+}
+
+// Create operation execute method, creates a new table with table_name in the database with the data in the operation
+func (operation *CreateOperation) Call(database *Database) ([]byte, error) {
+	err := database.Create(operation.TableName, operation.Data)
+	return nil, err
+}
+
+// Sql create index operation, for creating new indexes on existing tables
+type CreateIndexOperation struct {
+	IndexName   string
+	TableName   string
+	ColumnNames []string
+	Unique      bool
+}
+
+// Create index operation execute method, creates a new index on the columns of a table by table_name
+func (operation *CreateIndexOperation) Call(database *Database) ([]byte, error) {
+	err := database.CreateIndex(operation.TableName, &Index{Name: operation.IndexName, ColumnNames: operation.ColumnNames, Unique: operation.Unique})
+	return nil, err
+}
+
+// Sql insert operation, for inserting data to existing tables.
+// The rows are either given as values or selected from the database
+type InsertOperation struct {
+	TableName   string
+	ColumnNames []string         // Columns to insert the values to, empty for all columns of the table
+	Rows        [][]Value        // Rows of values in the order of the columns
+	Tokens      [][]*Token       // Tokens of the values of the rows, nil if the values were not parsed
+	Select      *SelectOperation // Select to get the rows from, nil if the rows are given as values
+}
+
+// Insert operation execute method, inserts rows in the operation to a table by the table_name in the operation.
+// Either all of the rows are inserted or none
+func (operation *InsertOperation) Call(database *Database) ([]byte, error) {
+	readTableNames := []string{}
+	if operation.Select != nil {
+		readTableNames = operation.Select.getTableNames()
+	}
+
+	err := database.write([]string{operation.TableName}, readTableNames, func() error {
+		table, err := database.Get(operation.TableName)
+		if err != nil {
+			return err
+		}
+
+		columnNames := operation.ColumnNames
+		if len(columnNames) == 0 {
+			columnNames = Map(table.Columns, func(col *Column) string { return col.Name })
+		}
+
+		rows := operation.Rows
+		if operation.Select != nil {
+			data, err := operation.Select.getData(database)
+			if err != nil {
+				return err
+			}
+
+			rows = data.Data
+		}
+
+		data := [][]RowData{}
+		for rowIndex, row := range rows {
+			if len(row) != len(columnNames) {
+				return newError(ERROR_SYNTAX, "insert failed, %d columns but %d values", len(columnNames), len(row))
+			}
+
+			rowData := make([]RowData, len(columnNames))
+			for i, columnName := range columnNames {
+				rowData[i] = RowData{ColName: columnName, Value: row[i]}
+				if operation.Select == nil && operation.Tokens != nil {
+					rowData[i].Token = operation.Tokens[rowIndex][i]
+				}
+			}
+
+			data = append(data, rowData)
+		}
+
+		return table.InsertRows(data)
+	})
+
+	return nil, err
+}
+
+// Sql select operation, for fetching data from the database
+type SelectOperation struct {
+	TableName   string
+	Alias       string
+	Joins       []*Join
+	Projections []*Projection
+	Condition   Condition
+	GroupBy     []*Projection
+	Having      Condition
+	Sorters     []*Sorter
+	Limiter     *Limiter
+}
+
+// Select operation execute method, fetches data from a table by table_name
+func (operation *SelectOperation) Call(database *Database) ([]byte, error) {
+	var data *TableData
+	err := database.read(operation.getTableNames(), func() error {
+		var err error
+		data, err = operation.getData(database)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes, nil
+}
+
+// Get the names of the tables the operation selects from
+func (operation *SelectOperation) getTableNames() []string {
+	return append([]string{operation.TableName}, Map(operation.Joins, func(join *Join) string { return join.Table.TableName })...)
+}
+
+// Get the selected data by executing the plan of the select, the tables must be locked for reading
+func (operation *SelectOperation) getData(database *Database) (*TableData, error) {
+	plan, err := operation.plan(database)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.getData()
+}
+
+// Plan the select, the tables must be locked for reading
+func (operation *SelectOperation) plan(database *Database) (*ProjectNode, error) {
+	table, err := database.Get(operation.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var source PlanNode
+	var schema *Table
+	condition := operation.Condition
+	isSorted := false
+	if operation.Alias == "" && len(operation.Joins) == 0 {
+		sorters := operation.Sorters
+		if operation.isGrouped() {
+			sorters = nil
+		}
+
+		source, isSorted, err = planScan(table.Name, table, condition, sorters)
+		if err != nil {
+			return nil, err
+		}
+
+		schema, condition = table, nil
+	} else {
+		reference := &TableReference{TableName: operation.TableName, Alias: operation.Alias}
+		names := []string{reference.Name()}
+		tables := []*Table{table.qualify(reference.Name())}
+		for _, join := range operation.Joins {
+			other, err := database.Get(join.Table.TableName)
+			if err != nil {
+				return nil, err
+			}
+
+			names = append(names, join.Table.Name())
+			tables = append(tables, other.qualify(join.Table.Name()))
+		}
+
+		source, condition, err = planJoins(names, tables, operation.Joins, condition)
+		if err != nil {
+			return nil, err
+		}
+
+		schema = joinSchema(tables)
+	}
+
+	if condition != nil {
+		condition, err = bindCondition(schema, condition)
+		if err != nil {
+			return nil, err
+		}
+
+		source = &FilterNode{Input: source, Condition: condition}
+	}
+
+	if operation.isGrouped() {
+		return planGroup(source, schema, operation.Projections, operation.GroupBy, operation.Having, operation.Sorters, operation.Limiter)
+	}
+
+	return planOutput(source, schema, isSorted, operation.Projections, operation.Sorters, operation.Limiter)
+}
+
+// Check if the select operation groups or aggregates the rows
+func (operation *SelectOperation) isGrouped() bool {
+	return len(operation.GroupBy) > 0 || operation.Having != nil || IsTrueForAny(operation.Projections, (*Projection).IsAggregate)
+}
+
+// Sql update operation, for updating values in existing tables
+type UpdateOperation struct {
+	TableName   string
+	Assignments []*Assignment
+	Condition   Condition
+}
+
+// Update operation execute method, updates row of a table by table_name
+func (operation *UpdateOperation) Call(database *Database) ([]byte, error) {
+	err := database.write([]string{operation.TableName}, []string{}, func() error {
+		table, err := database.Get(operation.TableName)
+		if err != nil {
+			return err
+		}
+
+		return table.Update(operation.Assignments, operation.Condition)
+	})
+
+	return nil, err
+}
+
+// Sql delete operation, for deleting rows in existing tables
+type DeleteOperation struct {
+	TableName string
+	Condition Condition
+}
+
+// Delete operation execute method, deletes rows included in the condition from a table by table_name
+func (operation *DeleteOperation) Call(database *Database) ([]byte, error) {
+	err := database.write([]string{operation.TableName}, []string{}, func() error {
+		table, err := database.Get(operation.TableName)
+		if err != nil {
+			return err
+		}
+
+		return table.Delete(operation.Condition)
+	})
+
+	return nil, err
+}
+
+// Sql drop operation, for deleting tables from the database
+type DropOperation struct {
+	TableName string
+}
+
+// Drop operation execute method, deletes the table from the database
+func (operation *DropOperation) Call(database *Database) ([]byte, error) {
+	err := database.Delete(operation.TableName)
+	return nil, err
+}
+
+// Sql drop index operation, for deleting indexes from the database
+type DropIndexOperation struct {
+	IndexName string
+}
+
+// Drop index operation execute method, deletes the index from the database
+func (operation *DropIndexOperation) Call(database *Database) ([]byte, error) {
+	err := database.DeleteIndex(operation.IndexName)
+	return nil, err
+}
+
+// Sql explain operation, for describing how a select operation would be executed
+type ExplainOperation struct {
+	Select *SelectOperation
+}
+
+// Explain operation execute method, returns the plan of the select without executing it
+func (operation *ExplainOperation) Call(database *Database) ([]byte, error) {
+	var description *PlanDescription
+	err := database.read(operation.Select.getTableNames(), func() error {
+		plan, err := operation.Select.plan(database)
+		if err != nil {
+			return err
+		}
+
+		description = plan.explain()
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := json.Marshal(description)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes, nil
+}
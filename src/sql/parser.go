@@ -1,340 +1,1093 @@
-package sql
-
-import (
-	"fmt"
-	"strings"
-)
-
-// Parse tokens to sql expression
-func Parse(tokens []*Token) (Operation, error) {
-	if len(tokens) <= 0 {
-		return nil, fmt.Errorf("parser: no operation could be created, no tokens")
-	}
-
-	switch strings.ToUpper(tokens[0].Value) {
-	case "SELECT":
-		return parseSelect(tokens, 1)
-	case "CREATE":
-		return parseCreate(tokens, 1)
-	case "INSERT":
-		return parseInsert(tokens, 1)
-	case "UPDATE":
-		return parseUpdate(tokens, 1)
-	case "DELETE":
-		return parseDelete(tokens, 1)
-	case "DROP":
-		return parseDrop(tokens, 1)
-	}
-
-	return nil, fmt.Errorf("parser: no operation could be created, invalid operation")
-}
-
-// Parse a select operation
-func parseSelect(tokens []*Token, index int) (Operation, error) {
-	if len(tokens) <= 1 {
-		return nil, fmt.Errorf("parser: select operation could not be created, missing columns")
-	}
-
-	columnNames := []string{}
-	for i := index; i < len(tokens); i += 2 {
-		columnName := tokens[i].Value
-		columnNames = append(columnNames, columnName)
-
-		if len(tokens) > i+1 && tokens[i+1].Type == TOKEN_COMMA {
-			continue
-		}
-
-		index = i + 1
-		break
-	}
-
-	if len(tokens) <= index || strings.ToUpper(tokens[index].Value) != "FROM" {
-		return nil, fmt.Errorf("parser: select operation could not be created, missing the 'from' keyword")
-	}
-
-	if len(tokens) <= index + 1 {
-		return nil, fmt.Errorf("parser: select operation could not be created, missing tablename")
-	}
-
-	tableName := tokens[index+1].Value
-	index += 2
-
-	filters := []*Filter{}
-	sorters := []*Sorter{}
-	for index < len(tokens) {
-		switch strings.ToUpper(tokens[index].Value) {
-		case "WHERE":
-			f, i := parseFilter(tokens, index+1)
-			filters = append(filters, f...)
-			index = i
-			continue
-		case "ORDER":
-			s, i, err := parseSorter(tokens, index+1)
-
-			if err != nil {
-				return nil, err
-			}
-
-			sorters = append(sorters, s)
-			index = i
-			continue
-		}
-
-		return nil, fmt.Errorf("parser: select operation could not be created, invalid keyword '%s' after tablename", tokens[index].Value)
-	}
-
-	return &SelectOperation{
-		ColumnNames: columnNames,
-		TableName:   tableName,
-		Filters:     filters,
-		Sorters:     sorters,
-	}, nil
-}
-
-// Parse a create operation
-func parseCreate(tokens []*Token, index int) (Operation, error) {
-	if strings.ToUpper(tokens[index].Value) != "TABLE" || tokens[index+2].Value != "(" {
-		return nil, fmt.Errorf("create operation could not be created, trying to create something other than a table or missing parentheses")
-	}
-
-	tableName := tokens[index+1].Value
-	data := []ColData{}
-
-	for i := index + 3; i < len(tokens); i += 3 {
-		colName := tokens[i].Value
-		colType, _ := GetType(tokens[i+1].Value)
-		data = append(data, ColData{ColName: colName, ColType: colType})
-
-		if tokens[i+2].Type == TOKEN_COMMA {
-			continue
-		}
-
-		if tokens[i+2].Value == ")" {
-			break
-		}
-	}
-
-	return &CreateOperation{
-		TableName: tableName,
-		Data:      data,
-	}, nil
-}
-
-// Parse an insert operation
-func parseInsert(tokens []*Token, index int) (Operation, error) {
-	if strings.ToUpper(tokens[index].Value) != "INTO" || tokens[index+2].Value != "(" {
-		return nil, fmt.Errorf("insert operation could not be created, missing into keyword or parentheses")
-	}
-
-	tableName := tokens[index+1].Value
-	data := []RowData{}
-
-	for i := index + 3; i < len(tokens); i += 2 {
-		columnName := tokens[i].Value
-		data = append(data, RowData{ColName: columnName})
-
-		if tokens[i+1].Type == TOKEN_COMMA {
-			continue
-		}
-
-		if tokens[i+1].Value == ")" {
-			index = i + 2
-			break
-		}
-	}
-
-	if strings.ToUpper(tokens[index].Value) != "VALUES" || tokens[index+1].Value != "(" {
-		return nil, fmt.Errorf("insert operation could not be created, missing values keyword or parenthesis")
-	}
-
-	valIndex := 0
-	for i := index + 2; i < len(tokens); i += 2 {
-		value := tokens[i].Value
-		data[valIndex].Value = value
-
-		valIndex++
-
-		if tokens[i+1].Type == TOKEN_COMMA {
-			continue
-		}
-
-		if tokens[i+1].Value == ")" {
-			break
-		}
-	}
-
-	return &InsertOperation{
-		TableName: tableName,
-		Data:      data,
-	}, nil
-}
-
-// Parse an update operation
-func parseUpdate(tokens []*Token, index int) (Operation, error) {
-	if tokens[index+1].Value != "(" {
-		return nil, fmt.Errorf("update operation could not be created, missing parenthesis")
-	}
-
-	tableName := tokens[index].Value
-	data := []RowData{}
-
-	for i := index + 2; i < len(tokens); i += 2 {
-		columnName := tokens[i].Value
-		data = append(data, RowData{ColName: columnName})
-
-		if tokens[i+1].Type == TOKEN_COMMA {
-			continue
-		}
-
-		if tokens[i+1].Value == ")" {
-			index = i + 2
-			break
-		}
-	}
-
-	if strings.ToUpper(tokens[index].Value) != "VALUES" || tokens[index+1].Value != "(" {
-		return nil, fmt.Errorf("update operation could not be created, missing values keyword or parenthesis")
-	}
-
-	valIndex := 0
-	for i := index + 2; i < len(tokens); i += 2 {
-		value := tokens[i].Value
-		data[valIndex].Value = value
-		valIndex++
-
-		if tokens[i+1].Type == TOKEN_COMMA {
-			continue
-		}
-
-		if tokens[i+1].Value == ")" {
-			index = i + 2
-			break
-		}
-	}
-
-	filters := []*Filter{}
-	for index < len(tokens) {
-		switch strings.ToUpper(tokens[index].Value) {
-		case "WHERE":
-			f, i := parseFilter(tokens, index+1)
-			filters = append(filters, f...)
-			index = i
-			continue
-		}
-
-		return nil, fmt.Errorf("update operation could not be created, invalid syntax after table name")
-	}
-
-	return &UpdateOperation{
-		TableName: tableName,
-		Data:      data,
-		Filters:   filters,
-	}, nil
-}
-
-// Parse a delete operation
-func parseDelete(tokens []*Token, index int) (Operation, error) {
-	if strings.ToUpper(tokens[index].Value) != "FROM" {
-		return nil, fmt.Errorf("delete operation could not be created, missing from keyword")
-	}
-
-	tableName := tokens[index+1].Value
-	filters := []*Filter{}
-	index += 2
-
-	for index < len(tokens) {
-		switch strings.ToUpper(tokens[index].Value) {
-		case "WHERE":
-			f, i := parseFilter(tokens, index+1)
-			filters = append(filters, f...)
-			index = i
-			continue
-		}
-
-		return nil, fmt.Errorf("delete operation could not be created, invalid syntax after table name")
-	}
-
-	return &DeleteOperation{
-		TableName: tableName,
-		Filters:   filters,
-	}, nil
-}
-
-// Parse a drop operation
-func parseDrop(tokens []*Token, index int) (Operation, error) {
-	if strings.ToUpper(tokens[index].Value) != "TABLE" {
-		return nil, fmt.Errorf("drop operation could not be created, missing table keyword")
-	}
-
-	tableName := tokens[index+1].Value
-	return &DropOperation{
-		TableName: tableName,
-	}, nil
-}
-
-// Parse a where expression.
-// for example 0 < x < 1 returns two filters x > 0 and x < 1
-func parseFilter(tokens []*Token, index int) ([]*Filter, int) {
-	filters := []*Filter{}
-	value1 := tokens[index].Value
-
-	index++
-	operator1 := GetEqualityOperator(tokens[index].Value)
-	if tokens[index+1].Type == TOKEN_OPERATOR {
-		operator1 = operator1 | GetEqualityOperator(tokens[index+1].Value)
-		index++
-	}
-
-	index++
-	value2 := tokens[index].Value
-
-	index++
-	if index < len(tokens) && tokens[index].Type == TOKEN_OPERATOR {
-		operator2 := GetEqualityOperator(tokens[index].Value)
-		if tokens[index+1].Type == TOKEN_OPERATOR {
-			operator2 = operator2 | GetEqualityOperator(tokens[index+1].Value)
-			index++
-		}
-
-		index++
-		value3 := tokens[index].Value
-
-		filters = append(filters, &Filter{
-			ColumnName:   value2,
-			Operator:     operator1.Inverse(),
-			CompareValue: value1,
-		}, &Filter{
-			ColumnName:   value2,
-			Operator:     operator2,
-			CompareValue: value3,
-		})
-
-		return filters, index + 1
-	}
-
-	filters = append(filters, &Filter{
-		ColumnName:   value1,
-		Operator:     operator1,
-		CompareValue: value2,
-	})
-
-	return filters, index + 1
-}
-
-// Parse order by expression
-func parseSorter(tokens []*Token, index int) (*Sorter, int, error) {
-	if strings.ToUpper(tokens[index].Value) != "BY" {
-		return nil, -1, fmt.Errorf("order could not be created, missing by keyword")
-	}
-
-	columnName := tokens[index+1].Value
-	direction, err := GetSortDirection(tokens[index+2].Value)
-	if err != nil {
-		return nil, -1, err
-	}
-
-	return &Sorter{
-		ColumnName: columnName,
-		Direction:  direction,
-	}, index + 3, nil
-}
+package sql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Maximum depth of nested expressions, deeper expressions are syntax errors so that the stack cannot overflow
+const maxParserDepth = 1000
+
+// Parse tokens of a single statement to an operation.
+// The tokens are parsed to a syntax tree, which is compiled to the operation
+func Parse(tokens []*Token) (Operation, error) {
+	statement, err := ParseStatement(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return compile(tokens, statement)
+}
+
+// Parse a script of statements separated by semicolons to operations, empty statements are skipped.
+// Every statement is parsed before any of them is executed, so a syntax error in any statement fails the whole script.
+// Errors have the number of the statement and the position of the token counted from the start of the script
+func ParseScript(tokens []*Token) ([]Operation, error) {
+	operations := []Operation{}
+	start := 0
+	for index := 0; index <= len(tokens); index++ {
+		if index < len(tokens) && tokens[index].Type != TOKEN_SEMICOLON {
+			continue
+		}
+
+		if index > start {
+			operation, err := Parse(tokens[start:index])
+			if err != nil {
+				return nil, getStatementError(err, len(operations)+1, start)
+			}
+
+			operations = append(operations, operation)
+		}
+
+		start = index + 1
+	}
+
+	if len(operations) <= 0 {
+		return nil, newSyntaxError(tokens, 0, "parser: no operation could be created, no statements")
+	}
+
+	return operations, nil
+}
+
+// Parse tokens of a single statement to a syntax tree.
+// Invalid input returns a syntax error at the token the error was found at, the parser never panics
+func ParseStatement(tokens []*Token) (Statement, error) {
+	if len(tokens) <= 0 {
+		return nil, newSyntaxError(tokens, 0, "parser: no operation could be created, no tokens")
+	}
+
+	for index, token := range tokens {
+		if token.Type == TOKEN_INVALID {
+			return nil, newSyntaxError(tokens, index, "parser: %s", getInvalidTokenMessage(token))
+		}
+	}
+
+	parser := &parser{tokens: tokens}
+	statement, err := parser.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	if !parser.isEnd() {
+		return nil, parser.errorf("parser: operation could not be created, unexpected '%s' after the end of the statement", parser.peek().Value)
+	}
+
+	return statement, nil
+}
+
+// Get the reason a token is invalid, quoted values and comments that are not closed are not included in the message
+func getInvalidTokenMessage(token *Token) string {
+	switch {
+	case strings.HasPrefix(token.Value, "'"):
+		return "value is missing the closing quote"
+	case strings.HasPrefix(token.Value, "\""):
+		return "identifier is missing the closing quote"
+	case strings.HasPrefix(token.Value, "/*"):
+		return "comment is missing the closing */"
+	}
+
+	return fmt.Sprintf("invalid character '%s'", token.Value)
+}
+
+// Recursive descent parser of the tokens of a single statement.
+// Tokens are read only through the methods of the parser, which check the bounds of the tokens
+type parser struct {
+	tokens []*Token
+	index  int // Index of the next token
+	depth  int // Depth of the nested expressions at the next token
+}
+
+// Get the next token without reading it, nil after the last token
+func (parser *parser) peek() *Token {
+	if parser.isEnd() {
+		return nil
+	}
+
+	return parser.tokens[parser.index]
+}
+
+// Check if every token has been read
+func (parser *parser) isEnd() bool {
+	return parser.index >= len(parser.tokens)
+}
+
+// Create a syntax error at the next token
+func (parser *parser) errorf(format string, args ...any) *Error {
+	return newSyntaxError(parser.tokens, parser.index, format, args...)
+}
+
+// Get the keyword of the next token in upper case, empty string if the next token is not a keyword
+func (parser *parser) keyword() string {
+	return parser.keywordAt(parser.index)
+}
+
+// Get the keyword of a token in upper case, empty string if the token is not a keyword or there is no token at the index
+func (parser *parser) keywordAt(index int) string {
+	if index < 0 || index >= len(parser.tokens) || parser.tokens[index].Type != TOKEN_KEYWORD {
+		return ""
+	}
+
+	return strings.ToUpper(parser.tokens[index].Value)
+}
+
+// Read the next token if it is one of the keywords.
+// Returns the keyword, empty string if the token was not read
+func (parser *parser) acceptKeyword(keywords ...string) string {
+	keyword := parser.keyword()
+	if keyword == "" || !slices.Contains(keywords, keyword) {
+		return ""
+	}
+
+	parser.index++
+	return keyword
+}
+
+// Read a keyword that must be the next token, context is the name of the operation used in the error
+func (parser *parser) expectKeyword(keyword string, context string) error {
+	if parser.acceptKeyword(keyword) == "" {
+		return parser.errorf("parser: %s could not be created, missing %s keyword", context, strings.ToLower(keyword))
+	}
+
+	return nil
+}
+
+// Check if the next token is a symbol such as a parenthesis or a comma, names and values in quotes are never symbols
+func (parser *parser) isSymbol(symbol string) bool {
+	token := parser.peek()
+	return token != nil && token.Type != TOKEN_IDENTIFIER && token.Type != TOKEN_STRING_LITERAL && token.Value == symbol
+}
+
+// Read the next token if it is the symbol, returns true if the token was read
+func (parser *parser) acceptSymbol(symbol string) bool {
+	if !parser.isSymbol(symbol) {
+		return false
+	}
+
+	parser.index++
+	return true
+}
+
+// Read a symbol that must be the next token, context is the name of the operation used in the error
+func (parser *parser) expectSymbol(symbol string, context string) error {
+	if !parser.acceptSymbol(symbol) {
+		return parser.errorf("parser: %s could not be created, missing '%s'", context, symbol)
+	}
+
+	return nil
+}
+
+// Read a name of a table, a column or an index, keywords and values are not valid names
+func (parser *parser) parseIdentifier(context string) (string, error) {
+	token := parser.peek()
+	if token == nil {
+		return "", parser.errorf("parser: %s could not be read, missing %s name", context, context)
+	}
+
+	if token.Type != TOKEN_IDENTIFIER {
+		return "", parser.errorf("parser: %s could not be read, expected a %s name but got '%s'", context, context, token.Value)
+	}
+
+	parser.index++
+	return token.Value, nil
+}
+
+// Read a name of a column
+func (parser *parser) parseColumnName() (string, error) {
+	return parser.parseIdentifier("column")
+}
+
+// Read a column name as a column expression that keeps the position of the name
+func (parser *parser) parseColumn() (*ColumnExpr, error) {
+	position := parser.index
+	columnName, err := parser.parseColumnName()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ColumnExpr{Position: position, Name: columnName}, nil
+}
+
+// Read a comma separated list in parentheses, each item is read with the parse function
+func parseList[T any](parser *parser, context string, parse func() (T, error)) ([]T, error) {
+	err := parser.expectSymbol("(", context)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []T{}
+	for {
+		item, err := parse()
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+		if parser.acceptSymbol(",") {
+			continue
+		}
+
+		if parser.acceptSymbol(")") {
+			return items, nil
+		}
+
+		return nil, parser.errorf("parser: list could not be read, missing comma or closing parenthesis")
+	}
+}
+
+// Parse a statement by the keyword it starts with
+func (parser *parser) parseStatement() (Statement, error) {
+	position := parser.index
+	var statement Statement
+	var err error
+
+	switch keyword := parser.acceptKeyword("SELECT", "CREATE", "INSERT", "UPDATE", "DELETE", "DROP", "EXPLAIN", "BEGIN", "START", "COMMIT", "ROLLBACK"); keyword {
+	case "SELECT":
+		statement, err = parser.parseSelect(position)
+	case "CREATE":
+		statement, err = parser.parseCreate(position)
+	case "INSERT":
+		statement, err = parser.parseInsert(position)
+	case "UPDATE":
+		statement, err = parser.parseUpdate(position)
+	case "DELETE":
+		statement, err = parser.parseDelete(position)
+	case "DROP":
+		statement, err = parser.parseDrop(position)
+	case "EXPLAIN":
+		statement, err = parser.parseExplain(position)
+	case "BEGIN", "START":
+		statement, err = parser.parseTransaction(position, TRANSACTION_BEGIN, keyword)
+	case "COMMIT":
+		statement, err = parser.parseTransaction(position, TRANSACTION_COMMIT, keyword)
+	case "ROLLBACK":
+		statement, err = parser.parseTransaction(position, TRANSACTION_ROLLBACK, keyword)
+	default:
+		err = parser.errorf("parser: no operation could be created, invalid operation")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+// Parse a select statement, the select keyword should already be read.
+// Clauses after the from clause can be in any order, many where and having clauses are combined with and
+func (parser *parser) parseSelect(position int) (*SelectStatement, error) {
+	if parser.isEnd() {
+		return nil, parser.errorf("parser: select operation could not be created, missing columns")
+	}
+
+	statement := &SelectStatement{Position: position}
+	for {
+		projection, err := parser.parseProjection()
+		if err != nil {
+			return nil, err
+		}
+
+		statement.Projections = append(statement.Projections, projection)
+		if !parser.acceptSymbol(",") {
+			break
+		}
+	}
+
+	if parser.acceptKeyword("FROM") == "" {
+		return nil, parser.errorf("parser: select operation could not be created, missing the 'from' keyword")
+	}
+
+	from, err := parser.parseTable()
+	if err != nil {
+		return nil, err
+	}
+
+	statement.From = from
+	for slices.Contains([]string{"JOIN", "INNER", "LEFT"}, parser.keyword()) {
+		join, err := parser.parseJoin()
+		if err != nil {
+			return nil, err
+		}
+
+		statement.Joins = append(statement.Joins, join)
+	}
+
+	for {
+		clausePosition := parser.index
+		switch parser.acceptKeyword("WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "OFFSET") {
+		case "WHERE":
+			condition, err := parser.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+
+			statement.Where = andExpr(statement.Where, condition, clausePosition)
+		case "GROUP":
+			err := parser.expectKeyword("BY", "group")
+			if err != nil {
+				return nil, err
+			}
+
+			for {
+				expression, err := parser.parseExpression()
+				if err != nil {
+					return nil, err
+				}
+
+				statement.GroupBy = append(statement.GroupBy, expression)
+				if !parser.acceptSymbol(",") {
+					break
+				}
+			}
+		case "HAVING":
+			condition, err := parser.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+
+			statement.Having = andExpr(statement.Having, condition, clausePosition)
+		case "ORDER":
+			err := parser.expectKeyword("BY", "order")
+			if err != nil {
+				return nil, err
+			}
+
+			for {
+				order, err := parser.parseOrder()
+				if err != nil {
+					return nil, err
+				}
+
+				statement.OrderBy = append(statement.OrderBy, order)
+				if !parser.acceptSymbol(",") {
+					break
+				}
+			}
+		case "LIMIT":
+			statement.Limit, err = parser.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+		case "OFFSET":
+			statement.Offset, err = parser.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+		default:
+			if token := parser.peek(); token != nil && token.Type == TOKEN_KEYWORD {
+				return nil, parser.errorf("parser: select operation could not be created, invalid keyword '%s' after tablename", token.Value)
+			}
+
+			return statement, nil
+		}
+	}
+}
+
+// Parse a single item of a select list, * or an expression
+func (parser *parser) parseProjection() (Expr, error) {
+	position := parser.index
+	if parser.acceptSymbol("*") {
+		return &ColumnExpr{Position: position, Name: "*"}, nil
+	}
+
+	return parser.parseExpression()
+}
+
+// Parse a table name with an optional alias, for example `artists AS a` or `artists a`
+func (parser *parser) parseTable() (*TableClause, error) {
+	table := &TableClause{Position: parser.index}
+	name, err := parser.parseIdentifier("table")
+	if err != nil {
+		return nil, err
+	}
+
+	table.Name = name
+	if parser.acceptKeyword("AS") != "" {
+		table.Alias, err = parser.parseIdentifier("alias")
+		if err != nil {
+			return nil, err
+		}
+
+		return table, nil
+	}
+
+	if token := parser.peek(); token != nil && token.Type == TOKEN_IDENTIFIER {
+		table.Alias = token.Value
+		parser.index++
+	}
+
+	return table, nil
+}
+
+// Parse a join of form `[INNER | LEFT [OUTER]] JOIN table [alias] ON condition`
+func (parser *parser) parseJoin() (*JoinClause, error) {
+	join := &JoinClause{Position: parser.index, Type: JOIN_INNER}
+	if parser.acceptKeyword("INNER", "LEFT") == "LEFT" {
+		join.Type = JOIN_LEFT
+		parser.acceptKeyword("OUTER")
+	}
+
+	err := parser.expectKeyword("JOIN", "join")
+	if err != nil {
+		return nil, err
+	}
+
+	join.Table, err = parser.parseTable()
+	if err != nil {
+		return nil, err
+	}
+
+	err = parser.expectKeyword("ON", "join")
+	if err != nil {
+		return nil, err
+	}
+
+	join.Condition, err = parser.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	return join, nil
+}
+
+// Parse a single item of an order by list, the direction defaults to ascending
+func (parser *parser) parseOrder() (*OrderClause, error) {
+	order := &OrderClause{Position: parser.index, Direction: DIRECTION_ASCENDING}
+	expression, err := parser.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	order.Expression = expression
+	if parser.acceptKeyword("ASC", "DESC") == "DESC" {
+		order.Direction = DIRECTION_DESCENDING
+	}
+
+	return order, nil
+}
+
+// Parse a create table or a create index statement, the create keyword should already be read
+func (parser *parser) parseCreate(position int) (Statement, error) {
+	switch parser.acceptKeyword("TABLE", "INDEX", "UNIQUE") {
+	case "TABLE":
+		return parser.parseCreateTable(position)
+	case "INDEX":
+		return parser.parseCreateIndex(position, false)
+	case "UNIQUE":
+		err := parser.expectKeyword("INDEX", "create operation")
+		if err != nil {
+			return nil, err
+		}
+
+		return parser.parseCreateIndex(position, true)
+	}
+
+	return nil, parser.errorf("parser: create operation could not be created, trying to create something other than a table or an index")
+}
+
+// Parse a create table statement of form `CREATE TABLE name (column definitions)`
+func (parser *parser) parseCreateTable(position int) (Statement, error) {
+	tableName, err := parser.parseIdentifier("table")
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := parseList(parser, "create operation", parser.parseColumnDefinition)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateTableStatement{Position: position, TableName: tableName, Columns: columns}, nil
+}
+
+// Parse a single column definition of form `name TYPE [PRIMARY KEY] [NOT NULL] [UNIQUE] [DEFAULT value]`
+func (parser *parser) parseColumnDefinition() (*ColumnDefinition, error) {
+	column := &ColumnDefinition{Position: parser.index}
+	name, err := parser.parseColumnName()
+	if err != nil {
+		return nil, err
+	}
+
+	token := parser.peek()
+	if token == nil || token.Type != TOKEN_IDENTIFIER {
+		return nil, parser.errorf("parser: create operation could not be created, missing type of column %s", name)
+	}
+
+	column.Name, column.TypeName = name, token.Value
+	parser.index++
+
+	for {
+		switch parser.acceptKeyword("PRIMARY", "NOT", "UNIQUE", "DEFAULT") {
+		case "PRIMARY":
+			err = parser.expectKeyword("KEY", "create operation")
+			column.PrimaryKey = true
+		case "NOT":
+			err = parser.expectKeyword("NULL", "create operation")
+			column.NotNull = true
+		case "UNIQUE":
+			column.Unique = true
+		case "DEFAULT":
+			column.Default, err = parser.parseUnary()
+		default:
+			return column, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Parse a create index statement of form `CREATE [UNIQUE] INDEX name ON table (a, b)`
+func (parser *parser) parseCreateIndex(position int, unique bool) (Statement, error) {
+	indexName, err := parser.parseIdentifier("index")
+	if err != nil {
+		return nil, err
+	}
+
+	err = parser.expectKeyword("ON", "create operation")
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := parser.parseIdentifier("table")
+	if err != nil {
+		return nil, err
+	}
+
+	columnNames, err := parseList(parser, "create operation", parser.parseColumnName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateIndexStatement{Position: position, IndexName: indexName, TableName: tableName, ColumnNames: columnNames, Unique: unique}, nil
+}
+
+// Parse an insert statement.
+// Supports many rows of values `INSERT INTO t (a, b) VALUES (1, 2), (3, 4)` and
+// inserting selected rows `INSERT INTO t (a, b) SELECT c, d FROM u`, the column list is optional
+func (parser *parser) parseInsert(position int) (Statement, error) {
+	err := parser.expectKeyword("INTO", "insert operation")
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &InsertStatement{Position: position}
+	statement.TableName, err = parser.parseIdentifier("table")
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.isSymbol("(") {
+		statement.Columns, err = parseList(parser, "insert operation", parser.parseColumn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	selectPosition := parser.index
+	if parser.acceptKeyword("SELECT") != "" {
+		statement.Select, err = parser.parseSelect(selectPosition)
+		if err != nil {
+			return nil, err
+		}
+
+		return statement, nil
+	}
+
+	if parser.acceptKeyword("VALUES") == "" {
+		return nil, parser.errorf("parser: insert operation could not be created, missing values or select keyword")
+	}
+
+	for {
+		row, err := parseList(parser, "insert operation", parser.parseExpression)
+		if err != nil {
+			return nil, err
+		}
+
+		statement.Rows = append(statement.Rows, row)
+		if !parser.acceptSymbol(",") {
+			return statement, nil
+		}
+	}
+}
+
+// Parse an update statement.
+// Supports both `UPDATE t SET a = 1, b = 2` and `UPDATE t (a, b) VALUES (1, 2)` syntax
+func (parser *parser) parseUpdate(position int) (Statement, error) {
+	tableName, err := parser.parseIdentifier("table")
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &UpdateStatement{Position: position, TableName: tableName}
+	switch {
+	case parser.acceptKeyword("SET") != "":
+		statement.Assignments, err = parser.parseAssignments()
+	case parser.isSymbol("("):
+		statement.Assignments, err = parser.parseLegacyAssignments()
+	default:
+		err = parser.errorf("parser: update operation could not be created, missing set keyword or parenthesis")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		wherePosition := parser.index
+		if parser.acceptKeyword("WHERE") == "" {
+			return statement, nil
+		}
+
+		condition, err := parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		statement.Where = andExpr(statement.Where, condition, wherePosition)
+	}
+}
+
+// Parse comma separated assignments of form `column = expression`
+func (parser *parser) parseAssignments() ([]*AssignmentClause, error) {
+	assignments := []*AssignmentClause{}
+	for {
+		assignment := &AssignmentClause{Position: parser.index}
+		columnName, err := parser.parseColumnName()
+		if err != nil {
+			return nil, err
+		}
+
+		err = parser.expectSymbol("=", "update operation")
+		if err != nil {
+			return nil, err
+		}
+
+		assignment.ColumnName = columnName
+		assignment.Expression, err = parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		assignments = append(assignments, assignment)
+		if !parser.acceptSymbol(",") {
+			return assignments, nil
+		}
+	}
+}
+
+// Parse assignments of form `(a, b) VALUES (1, 2)`
+func (parser *parser) parseLegacyAssignments() ([]*AssignmentClause, error) {
+	position := parser.index
+	columns, err := parseList(parser, "update operation", parser.parseColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = parser.expectKeyword("VALUES", "update operation")
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := parseList(parser, "update operation", parser.parseExpression)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) != len(columns) {
+		return nil, newSyntaxError(parser.tokens, position, "parser: update operation could not be created, %d columns but %d values", len(columns), len(values))
+	}
+
+	assignments := []*AssignmentClause{}
+	for i, column := range columns {
+		assignments = append(assignments, &AssignmentClause{Position: column.Position, ColumnName: column.Name, Expression: values[i]})
+	}
+
+	return assignments, nil
+}
+
+// Parse a delete statement of form `DELETE FROM table [WHERE condition]`
+func (parser *parser) parseDelete(position int) (Statement, error) {
+	err := parser.expectKeyword("FROM", "delete operation")
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := parser.parseIdentifier("table")
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &DeleteStatement{Position: position, TableName: tableName}
+	for {
+		wherePosition := parser.index
+		if parser.acceptKeyword("WHERE") == "" {
+			return statement, nil
+		}
+
+		condition, err := parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		statement.Where = andExpr(statement.Where, condition, wherePosition)
+	}
+}
+
+// Parse a drop statement, drops a table or an index
+func (parser *parser) parseDrop(position int) (Statement, error) {
+	switch parser.acceptKeyword("TABLE", "INDEX") {
+	case "TABLE":
+		tableName, err := parser.parseIdentifier("table")
+		if err != nil {
+			return nil, err
+		}
+
+		return &DropTableStatement{Position: position, TableName: tableName}, nil
+	case "INDEX":
+		indexName, err := parser.parseIdentifier("index")
+		if err != nil {
+			return nil, err
+		}
+
+		return &DropIndexStatement{Position: position, IndexName: indexName}, nil
+	}
+
+	return nil, parser.errorf("parser: drop operation could not be created, missing table or index keyword")
+}
+
+// Parse an explain statement, only select statements can be explained
+func (parser *parser) parseExplain(position int) (Statement, error) {
+	selectPosition := parser.index
+	if parser.acceptKeyword("SELECT") == "" {
+		return nil, parser.errorf("parser: explain operation could not be created, only select operations can be explained")
+	}
+
+	statement, err := parser.parseSelect(selectPosition)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExplainStatement{Position: position, Select: statement}, nil
+}
+
+// Parse a transaction statement, `BEGIN`, `START TRANSACTION`, `COMMIT` or `ROLLBACK`.
+// The transaction keyword is optional except after start
+func (parser *parser) parseTransaction(position int, statement TransactionStatement, keyword string) (Statement, error) {
+	if parser.acceptKeyword("TRANSACTION") == "" && keyword == "START" {
+		return nil, parser.errorf("parser: transaction operation could not be created, missing transaction keyword after start")
+	}
+
+	return &TransactionControlStatement{Position: position, Statement: statement}, nil
+}
+
+// Parse an expression.
+// Operator precedence from lowest to highest is or, and, not, comparisons and is null, + -, * / %, unary -
+func (parser *parser) parseExpression() (Expr, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.keyword() == "OR" {
+		position := parser.index
+		parser.index++
+
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &LogicalExpr{Position: position, Operator: LOGICAL_OR, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+// Parse expressions combined with and
+func (parser *parser) parseAnd() (Expr, error) {
+	left, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.keyword() == "AND" {
+		position := parser.index
+		parser.index++
+
+		right, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &LogicalExpr{Position: position, Operator: LOGICAL_AND, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+// Parse a negated expression or a predicate
+func (parser *parser) parseNot() (Expr, error) {
+	err := parser.enter()
+	if err != nil {
+		return nil, err
+	}
+
+	defer parser.leave()
+
+	position := parser.index
+	if parser.acceptKeyword("NOT") != "" {
+		operand, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return &NotExpr{Position: position, Operand: operand}, nil
+	}
+
+	return parser.parsePredicate()
+}
+
+// Parse comparisons or an is null, in, between or like predicate, comparisons can be chained for example 0 < x < 1
+func (parser *parser) parsePredicate() (Expr, error) {
+	left, err := parser.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	position := parser.index
+	isNot := parser.keyword() == "NOT" && slices.Contains([]string{"IN", "BETWEEN", "LIKE"}, parser.keywordAt(parser.index+1))
+	if isNot {
+		parser.index++
+	}
+
+	switch parser.acceptKeyword("IN", "BETWEEN", "LIKE") {
+	case "IN":
+		values, err := parseList(parser, "in condition", parser.parseExpression)
+		if err != nil {
+			return nil, err
+		}
+
+		return &InExpr{Position: position, Operand: left, Values: values, IsNot: isNot}, nil
+	case "BETWEEN":
+		return parser.parseBetween(position, left, isNot)
+	case "LIKE":
+		return parser.parseLike(position, left, isNot)
+	}
+
+	if parser.acceptKeyword("IS") != "" {
+		isNot := parser.acceptKeyword("NOT") != ""
+		if parser.acceptKeyword("NULL") == "" {
+			return nil, parser.errorf("parser: condition could not be created, missing null keyword after is")
+		}
+
+		return &IsNullExpr{Position: position, Operand: left, IsNot: isNot}, nil
+	}
+
+	for token := parser.peek(); token != nil && token.Type == TOKEN_OPERATOR; token = parser.peek() {
+		position := parser.index
+		parser.index++
+
+		right, err := parser.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &ComparisonExpr{Position: position, Operator: GetEqualityOperator(token.Value), Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+// Parse the bounds of a between predicate, the between keyword should already be read
+func (parser *parser) parseBetween(position int, operand Expr, isNot bool) (Expr, error) {
+	lower, err := parser.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	err = parser.expectKeyword("AND", "between condition")
+	if err != nil {
+		return nil, err
+	}
+
+	upper, err := parser.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	return &BetweenExpr{Position: position, Operand: operand, Lower: lower, Upper: upper, IsNot: isNot}, nil
+}
+
+// Parse the pattern and the optional escape clause of a like predicate, the like keyword should already be read
+func (parser *parser) parseLike(position int, operand Expr, isNot bool) (Expr, error) {
+	pattern, err := parser.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	like := &LikeExpr{Position: position, Operand: operand, Pattern: pattern, IsNot: isNot}
+	if parser.acceptKeyword("ESCAPE") != "" {
+		like.Escape, err = parser.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return like, nil
+}
+
+// Parse expressions combined with addition or subtraction
+func (parser *parser) parseAdditive() (Expr, error) {
+	left, err := parser.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.isSymbol("+") || parser.isSymbol("-") {
+		position := parser.index
+		operator, _ := GetArithmeticOperator(parser.peek().Value)
+		parser.index++
+
+		right, err := parser.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &BinaryExpr{Position: position, Operator: operator, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+// Parse expressions combined with multiplication, division or remainder
+func (parser *parser) parseTerm() (Expr, error) {
+	left, err := parser.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.isSymbol("*") || parser.isSymbol("/") || parser.isSymbol("%") {
+		position := parser.index
+		operator, _ := GetArithmeticOperator(parser.peek().Value)
+		parser.index++
+
+		right, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &BinaryExpr{Position: position, Operator: operator, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+// Parse a negated expression or a primary expression
+func (parser *parser) parseUnary() (Expr, error) {
+	err := parser.enter()
+	if err != nil {
+		return nil, err
+	}
+
+	defer parser.leave()
+
+	position := parser.index
+	if parser.acceptSymbol("-") {
+		operand, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &UnaryExpr{Position: position, Operand: operand}, nil
+	}
+
+	return parser.parsePrimary()
+}
+
+// Parse a literal, a column, a function call such as COUNT(*) or an expression inside parentheses
+func (parser *parser) parsePrimary() (Expr, error) {
+	token := parser.peek()
+	if token == nil {
+		return nil, parser.errorf("parser: expression could not be created, unexpected end of expression")
+	}
+
+	position := parser.index
+	switch {
+	case token.Type == TOKEN_NUMBER_LITERAL:
+		parser.index++
+		return &LiteralExpr{Position: position, Kind: LITERAL_NUMBER, Value: NewValue(token.Value)}, nil
+	case token.Type == TOKEN_STRING_LITERAL:
+		parser.index++
+		return &LiteralExpr{Position: position, Kind: LITERAL_STRING, Value: NewValue(token.Value)}, nil
+	case parser.acceptKeyword("NULL") != "":
+		return &LiteralExpr{Position: position, Kind: LITERAL_NULL}, nil
+	case token.Type == TOKEN_IDENTIFIER:
+		parser.index++
+		if parser.isSymbol("(") {
+			return parser.parseFunction(position, token.Value)
+		}
+
+		return &ColumnExpr{Position: position, Name: token.Value}, nil
+	case parser.acceptSymbol("("):
+		expression, err := parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		err = parser.expectSymbol(")", "expression")
+		if err != nil {
+			return nil, err
+		}
+
+		return expression, nil
+	}
+
+	return nil, parser.errorf("parser: expression could not be created, unexpected token '%s'", token.Value)
+}
+
+// Parse the argument of a function call in parentheses, the argument is * or an expression
+func (parser *parser) parseFunction(position int, name string) (Expr, error) {
+	function := &FunctionExpr{Position: position, Name: name}
+	err := parser.expectSymbol("(", "function")
+	if err != nil {
+		return nil, err
+	}
+
+	function.Argument, err = parser.parseProjection()
+	if err != nil {
+		return nil, err
+	}
+
+	err = parser.expectSymbol(")", "function")
+	if err != nil {
+		return nil, err
+	}
+
+	return function, nil
+}
+
+// Increase the depth of nested expressions, fails if the expression is nested too deeply
+func (parser *parser) enter() error {
+	parser.depth++
+	if parser.depth > maxParserDepth {
+		return parser.errorf("parser: expression could not be created, expression is nested too deeply")
+	}
+
+	return nil
+}
+
+// Decrease the depth of nested expressions
+func (parser *parser) leave() {
+	parser.depth--
+}
+
+// Combine two conditions with and, the left condition can be nil
+func andExpr(left Expr, right Expr, position int) Expr {
+	if left == nil {
+		return right
+	}
+
+	return &LogicalExpr{Position: position, Operator: LOGICAL_AND, Left: left, Right: right}
+}
@@ -0,0 +1,156 @@
+package sql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStatement(t *testing.T) {
+	statement, err := ParseStatement(Tokenize([]byte("SELECT name, COUNT(*) FROM artists a WHERE NOT a.age > 1 + 2 * 3 OR name IS NULL ORDER BY name DESC LIMIT 5")))
+	if err != nil {
+		t.Fatalf("parse returned an error but should not have: %s", err.Error())
+	}
+
+	selectStatement, ok := statement.(*SelectStatement)
+	if !ok || len(selectStatement.Projections) != 2 || selectStatement.From.Name != "artists" || selectStatement.From.Alias != "a" {
+		t.Fatalf("wrong select statement: %+v", statement)
+	}
+
+	if function, ok := selectStatement.Projections[1].(*FunctionExpr); !ok || function.Argument.(*ColumnExpr).Name != "*" {
+		t.Fatalf("wrong function projection: %+v", selectStatement.Projections[1])
+	}
+
+	or, ok := selectStatement.Where.(*LogicalExpr)
+	if !ok || or.Operator != LOGICAL_OR {
+		t.Fatalf("or should have the lowest precedence: %+v", selectStatement.Where)
+	}
+
+	comparison, ok := or.Left.(*NotExpr).Operand.(*ComparisonExpr)
+	if !ok || comparison.Operator != GREATER {
+		t.Fatalf("not should have a lower precedence than a comparison: %+v", or.Left)
+	}
+
+	addition, ok := comparison.Right.(*BinaryExpr)
+	if !ok || addition.Operator != ARITHMETIC_ADD || addition.Right.(*BinaryExpr).Operator != ARITHMETIC_MULTIPLY {
+		t.Fatalf("multiplication should have a higher precedence than addition: %+v", comparison.Right)
+	}
+
+	if _, ok := or.Right.(*IsNullExpr); !ok {
+		t.Fatalf("wrong is null expression: %+v", or.Right)
+	}
+
+	if len(selectStatement.OrderBy) != 1 || selectStatement.OrderBy[0].Direction != DIRECTION_DESCENDING || selectStatement.Limit.(*LiteralExpr).Value.String != "5" {
+		t.Fatalf("wrong order and limit: %+v", selectStatement)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	queries := []string{
+		"CREATE",
+		"CREATE TABLE",
+		"CREATE TABLE t (",
+		"CREATE TABLE t (id",
+		"CREATE TABLE t (id INT PRIMARY",
+		"CREATE UNIQUE",
+		"INSERT",
+		"INSERT INTO t (",
+		"INSERT INTO t VALUES (1,",
+		"INSERT INTO t (a, b) VALUES (1)",
+		"UPDATE",
+		"UPDATE t",
+		"UPDATE t SET",
+		"UPDATE t SET a =",
+		"UPDATE t (a, b) VALUES (1)",
+		"SELECT",
+		"SELECT * FROM",
+		"SELECT * FROM t WHERE",
+		"SELECT * FROM t WHERE (a = 1",
+		"SELECT * FROM t JOIN u ON",
+		"SELECT * FROM t LIMIT -1",
+		"SELECT COUNT( FROM t",
+		"DELETE FROM t WHERE a IS",
+		"EXPLAIN DELETE FROM t",
+		"START",
+		"SELECT * FROM t " + strings.Repeat("WHERE -", 2*maxParserDepth),
+		"SELECT * FROM t WHERE " + strings.Repeat("(", 2*maxParserDepth),
+	}
+
+	for _, query := range queries {
+		_, err := Parse(Tokenize([]byte(query)))
+		if err == nil || GetError(err).Category != ERROR_SYNTAX {
+			t.Fatalf("parse should have returned a syntax error for %s, got %v", query, err)
+		}
+	}
+}
+
+func TestParseDuplicateColumns(t *testing.T) {
+	tests := []struct {
+		query    string
+		position int
+	}{
+		{"INSERT INTO t (id, id) VALUES (10, 11)", 6},
+		{"INSERT INTO t (a, b, a) SELECT * FROM u", 8},
+		{"UPDATE t SET age = age + 1, age = 3", 9},
+		{"UPDATE t (a, b, a) VALUES (1, 2, 3)", 7},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(Tokenize([]byte(test.query)))
+		if err == nil || GetError(err).Category != ERROR_SYNTAX || GetError(err).Position != test.position {
+			t.Fatalf("repeated column should have been a syntax error at token %d for %s, got %v", test.position, test.query, err)
+		}
+	}
+}
+
+func TestParseAggregateInCondition(t *testing.T) {
+	tests := []struct {
+		query    string
+		position int
+	}{
+		{"SELECT * FROM t WHERE COUNT(id) > 1", 5},
+		{"SELECT * FROM t WHERE id = 1 OR NOT MAX(id) IS NULL", 10},
+		{"SELECT * FROM t JOIN u ON SUM(t.id) = 1", 7},
+		{"UPDATE t SET a = 1 WHERE AVG(a) < 2", 7},
+		{"DELETE FROM t WHERE MIN(a) IN (1, 2)", 4},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(Tokenize([]byte(test.query)))
+		if err == nil || GetError(err).Category != ERROR_SYNTAX || GetError(err).Position != test.position {
+			t.Fatalf("aggregate in a condition should have been a syntax error at token %d for %s, got %v", test.position, test.query, err)
+		}
+	}
+
+	_, err := Parse(Tokenize([]byte("SELECT a, COUNT(*) FROM t GROUP BY a HAVING COUNT(*) > 1 ORDER BY COUNT(*)")))
+	if err != nil {
+		t.Fatal("aggregates should be allowed in the select list, having and order by")
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		"SELECT name, COUNT(*) FROM artists a LEFT JOIN albums b ON a.id = b.artist_id WHERE 0 < age < 10 GROUP BY name HAVING COUNT(*) > 1 ORDER BY name DESC LIMIT 5 OFFSET 1",
+		"CREATE TABLE artists (id INT PRIMARY KEY, name VARCHAR NOT NULL UNIQUE, age INT DEFAULT -1)",
+		"CREATE UNIQUE INDEX idx ON artists (name, age)",
+		"INSERT INTO artists (id, name) VALUES (1, 'a'), (2, 'it''s')",
+		"INSERT INTO archive SELECT * FROM artists WHERE NOT (age IS NULL OR age <= -1)",
+		"UPDATE artists SET age = -(age + 1) * 2 WHERE id = 1",
+		"UPDATE artists (name) VALUES ('b')",
+		"DELETE FROM artists WHERE \"select\" <> 'x'",
+		"EXPLAIN SELECT * FROM artists WHERE id >= 2",
+		"SELECT * FROM artists WHERE id NOT IN (1, -2) AND name BETWEEN 'a' AND 'c' OR name NOT LIKE 'x!%_' ESCAPE '!'",
+		"BEGIN; DROP INDEX idx; DROP TABLE artists; COMMIT",
+		"CREATE TABLE",
+		"SELECT * FROM t -- comment\n/* block */",
+	}
+
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, query string) {
+		tokens := Tokenize([]byte(query))
+		Parse(tokens)
+		ParseScript(tokens)
+	})
+}
@@ -0,0 +1,600 @@
+package sql
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A single node of a query plan tree, a node produces rows of a table from the rows of its inputs
+type PlanNode interface {
+	// Call visit for every row produced by the node until visit returns false, returns the table the rows belong to
+	execute(visit func(table *Table, rowIndex int) bool) (*Table, error)
+	// Describe the node and its inputs
+	explain() *PlanDescription
+}
+
+// Represents a node of a query plan as returned by explain
+type PlanDescription struct {
+	Operation string             `json:"operation"`
+	Table     string             `json:"table,omitempty"`
+	Index     string             `json:"index,omitempty"`
+	Condition string             `json:"condition,omitempty"`
+	Detail    string             `json:"detail,omitempty"`
+	Inputs    []*PlanDescription `json:"inputs,omitempty"`
+}
+
+// Reads every row of a table in order
+type ScanNode struct {
+	Name  string // Name of the table in the query
+	Table *Table
+}
+
+func (node *ScanNode) execute(visit func(table *Table, rowIndex int) bool) (*Table, error) {
+	for rowIndex := 0; rowIndex < node.Table.getRowCount(); rowIndex++ {
+		if !visit(node.Table, rowIndex) {
+			break
+		}
+	}
+
+	return node.Table, nil
+}
+
+func (node *ScanNode) explain() *PlanDescription {
+	return &PlanDescription{Operation: "TABLE SCAN", Table: node.Name}
+}
+
+// Reads the rows of a table found from an index, the rows are not checked against the condition
+type IndexScanNode struct {
+	Name      string // Name of the table in the query
+	Table     *Table
+	Index     *Index
+	Condition Condition // Condition to find the rows with, nil to read every row of the index
+	IsOrdered bool      // Rows are produced in the order of the index keys instead of the row order
+}
+
+func (node *IndexScanNode) execute(visit func(table *Table, rowIndex int) bool) (*Table, error) {
+	rowIndexes := []int{}
+	if node.Condition != nil {
+		rowIndexes, _ = node.Table.findIndexRows(node.Index, node.Condition)
+	} else {
+		node.Index.tree.ascend(nil, nil, func(item *btreeItem) bool {
+			rowIndexes = append(rowIndexes, item.rows...)
+			return true
+		})
+	}
+
+	if !node.IsOrdered {
+		slices.Sort(rowIndexes)
+	}
+
+	for _, rowIndex := range rowIndexes {
+		if !visit(node.Table, rowIndex) {
+			break
+		}
+	}
+
+	return node.Table, nil
+}
+
+func (node *IndexScanNode) explain() *PlanDescription {
+	description := &PlanDescription{Operation: "INDEX SCAN", Table: node.Name, Index: node.Index.Name, Condition: formatCondition(node.Condition)}
+	if node.IsOrdered {
+		description.Detail = fmt.Sprintf("ordered by %s", node.Index.ColumnNames[0])
+	}
+
+	return description
+}
+
+// Produces the rows of the input for which the condition is true
+type FilterNode struct {
+	Input     PlanNode
+	Condition Condition
+}
+
+func (node *FilterNode) execute(visit func(table *Table, rowIndex int) bool) (*Table, error) {
+	return node.Input.execute(func(table *Table, rowIndex int) bool {
+		return !table.isRowIncluded(rowIndex, node.Condition) || visit(table, rowIndex)
+	})
+}
+
+func (node *FilterNode) explain() *PlanDescription {
+	return &PlanDescription{Operation: "FILTER", Condition: formatCondition(node.Condition), Inputs: []*PlanDescription{node.Input.explain()}}
+}
+
+// Joins the rows of two inputs with a nested loop, see Table.Join
+type JoinNode struct {
+	Left      PlanNode
+	Right     PlanNode
+	Type      JoinType
+	Condition Condition
+}
+
+func (node *JoinNode) execute(visit func(table *Table, rowIndex int) bool) (*Table, error) {
+	left, err := collectRows(node.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := collectRows(node.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	return (&ScanNode{Table: left.Join(right, node.Type, node.Condition)}).execute(visit)
+}
+
+func (node *JoinNode) explain() *PlanDescription {
+	return &PlanDescription{
+		Operation: fmt.Sprintf("%s JOIN", node.Type.ToString()),
+		Condition: formatCondition(node.Condition),
+		Detail:    "nested loop",
+		Inputs:    []*PlanDescription{node.Left.explain(), node.Right.explain()},
+	}
+}
+
+// Groups the rows of the input, produces a row of the group columns and the aggregates for each group
+type GroupNode struct {
+	Input      PlanNode
+	GroupBy    []*Projection // Columns to group by
+	Aggregates []*Projection // Aggregates to calculate, including the aggregates used by having and order by
+}
+
+func (node *GroupNode) execute(visit func(table *Table, rowIndex int) bool) (*Table, error) {
+	rowIndexes := []int{}
+	table, err := node.Input.execute(func(table *Table, rowIndex int) bool {
+		rowIndexes = append(rowIndexes, rowIndex)
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	groupColumns, err := getProjectionColumns(table, node.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := table.groupRowIndexes(rowIndexes, groupColumns)
+	result, err := table.aggregateGroups(groups, groupColumns, node.Aggregates)
+	if err != nil {
+		return nil, err
+	}
+
+	return (&ScanNode{Table: result}).execute(visit)
+}
+
+func (node *GroupNode) explain() *PlanDescription {
+	aggregates := []string{}
+	for _, projection := range node.Aggregates {
+		if projection.IsAggregate() && !slices.Contains(aggregates, projection.Name()) {
+			aggregates = append(aggregates, projection.Name())
+		}
+	}
+
+	detail := fmt.Sprintf("aggregates %s", strings.Join(aggregates, ", "))
+	if len(node.GroupBy) > 0 {
+		detail = fmt.Sprintf("group by %s, %s", strings.Join(Map(node.GroupBy, (*Projection).Name), ", "), detail)
+	}
+
+	return &PlanDescription{Operation: "GROUP", Detail: detail, Inputs: []*PlanDescription{node.Input.explain()}}
+}
+
+// Sorts every row of the input in memory
+type SortNode struct {
+	Input   PlanNode
+	Sorters []*Sorter
+}
+
+func (node *SortNode) execute(visit func(table *Table, rowIndex int) bool) (*Table, error) {
+	sortData := []*SortData{}
+	table, err := node.Input.execute(func(table *Table, rowIndex int) bool {
+		sortData = append(sortData, &SortData{Index: rowIndex})
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := table.sort(sortData, node.Sorters); err != nil {
+		return nil, err
+	}
+
+	for _, data := range sortData {
+		if !visit(table, data.Index) {
+			break
+		}
+	}
+
+	return table, nil
+}
+
+func (node *SortNode) explain() *PlanDescription {
+	return &PlanDescription{Operation: "SORT", Detail: formatSorters(node.Sorters), Inputs: []*PlanDescription{node.Input.explain()}}
+}
+
+// Skips the offset rows of the input and stops reading the input when the limit is reached
+type LimitNode struct {
+	Input   PlanNode
+	Limiter *Limiter
+}
+
+func (node *LimitNode) execute(visit func(table *Table, rowIndex int) bool) (*Table, error) {
+	rowCount := 0
+	return node.Input.execute(func(table *Table, rowIndex int) bool {
+		if node.Limiter.IsFull(rowCount) {
+			return false
+		}
+
+		rowCount++
+		if rowCount > node.Limiter.Offset && !visit(table, rowIndex) {
+			return false
+		}
+
+		return !node.Limiter.IsFull(rowCount)
+	})
+}
+
+func (node *LimitNode) explain() *PlanDescription {
+	return &PlanDescription{Operation: "LIMIT", Detail: fmt.Sprintf("limit %d offset %d", node.Limiter.Limit, node.Limiter.Offset), Inputs: []*PlanDescription{node.Input.explain()}}
+}
+
+// Selects the columns of the rows of the input, the root of a select plan
+type ProjectNode struct {
+	Input       PlanNode
+	Projections []*Projection // Selected columns, the result columns of the aggregates if the input is grouped
+}
+
+func (node *ProjectNode) execute(visit func(table *Table, rowIndex int) bool) (*Table, error) {
+	return node.Input.execute(visit)
+}
+
+func (node *ProjectNode) explain() *PlanDescription {
+	return &PlanDescription{Operation: "PROJECT", Detail: strings.Join(Map(node.Projections, (*Projection).Name), ", "), Inputs: []*PlanDescription{node.Input.explain()}}
+}
+
+// Execute the plan and get the selected columns of the produced rows.
+// Columns are named as they are written in the select list, all columns selected with an asterisk keep the names of the input
+func (node *ProjectNode) getData() (*TableData, error) {
+	rowIndexes := []int{}
+	table, err := node.execute(func(table *Table, rowIndex int) bool {
+		rowIndexes = append(rowIndexes, rowIndex)
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := getProjectionColumns(table, node.Projections)
+	if err != nil {
+		return nil, err
+	}
+
+	columnNames := Map(node.Projections, (*Projection).Name)
+	if len(columnNames) == 1 && columnNames[0] == "*" {
+		columnNames = Map(columns, func(col *Column) string { return col.Name })
+	}
+
+	return &TableData{
+		Columns:     columnNames,
+		ColumnTypes: Map(columns, func(col *Column) string { return col.Type.ToString() }),
+		Data: Map(rowIndexes, func(rowIndex int) []Value {
+			return Map(columns, func(col *Column) Value { return col.Values[rowIndex] })
+		}),
+	}, nil
+}
+
+// Plan the reading of a table filtered by a condition.
+// An index is used if the condition compares the first column of an index, or if the rows can be read in the order of the sorters from an index.
+// The condition is bound to the columns of the table. Returns true if the rows are produced in the order of the sorters
+func planScan(name string, table *Table, condition Condition, sorters []*Sorter) (PlanNode, bool, error) {
+	condition, err := bindCondition(table, condition)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var scan PlanNode = &ScanNode{Name: name, Table: table}
+	isSorted := false
+	for _, index := range table.Indexes {
+		if _, _, ok := table.getIndexBounds(index, condition); ok {
+			isSorted = table.isIndexSorted(index, sorters)
+			scan = &IndexScanNode{Name: name, Table: table, Index: index, Condition: condition, IsOrdered: isSorted}
+			break
+		}
+	}
+
+	if _, ok := scan.(*ScanNode); ok {
+		for _, index := range table.Indexes {
+			if index.tree != nil && table.isIndexSorted(index, sorters) {
+				isSorted = true
+				scan = &IndexScanNode{Name: name, Table: table, Index: index, IsOrdered: true}
+				break
+			}
+		}
+	}
+
+	if condition != nil {
+		scan = &FilterNode{Input: scan, Condition: condition}
+	}
+
+	return scan, isSorted, nil
+}
+
+// Check if the keys of an index are in the order of the sorters, only a single ascending column is supported
+func (table *Table) isIndexSorted(index *Index, sorters []*Sorter) bool {
+	if len(sorters) != 1 || sorters[0].Function != AGGREGATE_NONE || sorters[0].Direction != DIRECTION_ASCENDING {
+		return false
+	}
+
+	indexCol, err := table.getColumnByName(index.ColumnNames[0])
+	if err != nil {
+		return false
+	}
+
+	col, err := table.getColumnByName(sorters[0].ColumnName)
+	return err == nil && col == indexCol
+}
+
+// Plan the sorting, the limiting and the selecting of the columns of the rows of an input
+func planOutput(input PlanNode, schema *Table, isSorted bool, projections []*Projection, sorters []*Sorter, limiter *Limiter) (*ProjectNode, error) {
+	if _, err := getProjectionColumns(schema, projections); err != nil {
+		return nil, err
+	}
+
+	if _, err := getSortColumns(schema, sorters); err != nil {
+		return nil, err
+	}
+
+	if len(sorters) > 0 && !isSorted {
+		input = &SortNode{Input: input, Sorters: sorters}
+	}
+
+	if limiter != nil {
+		input = &LimitNode{Input: input, Limiter: limiter}
+	}
+
+	return &ProjectNode{Input: input, Projections: projections}, nil
+}
+
+// Plan the grouping of the rows of an input, the groups are filtered by the having condition.
+// Schema is a table of the columns of the input, the having condition is bound to the columns of the groups.
+// Selected columns must be in the group by
+func planGroup(input PlanNode, schema *Table, projections []*Projection, groupBy []*Projection, having Condition, sorters []*Sorter, limiter *Limiter) (*ProjectNode, error) {
+	aggregates := slices.Clone(projections)
+	walkProjections(having, func(projection *Projection) { aggregates = append(aggregates, projection) })
+	for _, sorter := range sorters {
+		aggregates = append(aggregates, sorter.projection())
+	}
+
+	groupColumns, err := getProjectionColumns(schema, groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, projection := range projections {
+		if projection.IsAggregate() {
+			continue
+		}
+
+		col, err := schema.getColumnByName(projection.ColumnName)
+		if err != nil || !slices.Contains(groupColumns, col) {
+			return nil, locateError(newError(ERROR_SYNTAX, "column must be used in an aggregate function or group by: %s", projection.ColumnName), projection.Token)
+		}
+	}
+
+	// THE GROUPS HAVE NO ROWS, ONLY THE COLUMNS ARE NEEDED TO BIND THE HAVING CONDITION AND THE SORTERS
+	groups, err := schema.aggregateGroups([][]int{}, groupColumns, aggregates)
+	if err != nil {
+		return nil, err
+	}
+
+	input = &GroupNode{Input: input, GroupBy: groupBy, Aggregates: aggregates}
+	if having != nil {
+		having, err = bindCondition(groups, having)
+		if err != nil {
+			return nil, err
+		}
+
+		input = &FilterNode{Input: input, Condition: having}
+	}
+
+	return planOutput(input, groups, false, projections, sorters, limiter)
+}
+
+// Plan the joins of tables, the parts of the condition that compare the columns of a single table are filtered before the joins.
+// Tables on the right side of a left join are not filtered before the join, the rest of the condition is returned unbound
+func planJoins(names []string, tables []*Table, joins []*Join, condition Condition) (PlanNode, Condition, error) {
+	conditions := make([]Condition, len(tables))
+	var rest Condition
+	for _, c := range getAndConditions(condition) {
+		tableIndex := getConditionTable(c, tables)
+		if tableIndex == -1 || tableIndex > 0 && joins[tableIndex-1].Type == JOIN_LEFT {
+			rest = And(rest, c)
+			continue
+		}
+
+		conditions[tableIndex] = And(conditions[tableIndex], c)
+	}
+
+	node, _, err := planScan(names[0], tables[0], conditions[0], nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, join := range joins {
+		right, _, err := planScan(names[i+1], tables[i+1], conditions[i+1], nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		joinCondition, err := bindCondition(joinSchema(tables[:i+2]), join.Condition)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		node = &JoinNode{Left: node, Right: right, Type: join.Type, Condition: joinCondition}
+	}
+
+	return node, rest, nil
+}
+
+// Create a table without rows that has the columns of joined tables, the conditions of the joins are bound to it
+func joinSchema(tables []*Table) *Table {
+	schema := &Table{}
+	for _, table := range tables {
+		schema.Columns = append(schema.Columns, table.Columns...)
+	}
+
+	return schema
+}
+
+// Get the index of the only table that has the columns compared in a condition, -1 if the columns are in many tables
+func getConditionTable(condition Condition, tables []*Table) int {
+	colNames, ok := getConditionColumns(condition)
+	if !ok || len(colNames) == 0 {
+		return -1
+	}
+
+	tableIndex := -1
+	for _, colName := range colNames {
+		matches := []int{}
+		for i, table := range tables {
+			if _, err := table.getColumnByName(colName); err == nil {
+				matches = append(matches, i)
+			}
+		}
+
+		if len(matches) != 1 || tableIndex != -1 && matches[0] != tableIndex {
+			return -1
+		}
+
+		tableIndex = matches[0]
+	}
+
+	return tableIndex
+}
+
+// Get the names of the columns compared in a condition, returns false if the condition has aggregates or unknown parts
+func getConditionColumns(condition Condition) ([]string, bool) {
+	switch c := condition.(type) {
+	case *LogicalCondition:
+		left, ok := getConditionColumns(c.Left)
+		if !ok {
+			return nil, false
+		}
+
+		right, ok := getConditionColumns(c.Right)
+		return append(left, right...), ok
+	case *NotCondition:
+		return getConditionColumns(c.Condition)
+	case *Filter:
+		return []string{c.ColumnName}, c.Function == AGGREGATE_NONE
+	case *NullFilter:
+		return []string{c.ColumnName}, c.Function == AGGREGATE_NONE
+	case *InFilter:
+		return []string{c.ColumnName}, c.Function == AGGREGATE_NONE
+	case *LikeFilter:
+		return []string{c.ColumnName}, c.Function == AGGREGATE_NONE
+	case *ColumnComparison:
+		return []string{c.LeftColumn, c.RightColumn}, true
+	}
+
+	return nil, false
+}
+
+// Read every row produced by a node to a new table, the table of the node is used if every row is produced in order
+func collectRows(node PlanNode) (*Table, error) {
+	rowIndexes := []int{}
+	table, err := node.execute(func(table *Table, rowIndex int) bool {
+		rowIndexes = append(rowIndexes, rowIndex)
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	isEveryRow := len(rowIndexes) == table.getRowCount()
+	for i, rowIndex := range rowIndexes {
+		isEveryRow = isEveryRow && rowIndex == i
+	}
+
+	if isEveryRow {
+		return table, nil
+	}
+
+	result := &Table{Columns: Map(table.Columns, func(col *Column) *Column {
+		return &Column{Name: col.Name, Type: col.Type, Values: make([]Value, 0, len(rowIndexes))}
+	})}
+
+	for _, rowIndex := range rowIndexes {
+		result.appendRow(table, rowIndex)
+	}
+
+	return result, nil
+}
+
+// Format a condition tree for explain
+func formatCondition(condition Condition) string {
+	switch c := condition.(type) {
+	case *LogicalCondition:
+		return fmt.Sprintf("(%s %s %s)", formatCondition(c.Left), c.Operator.ToString(), formatCondition(c.Right))
+	case *NotCondition:
+		return fmt.Sprintf("NOT %s", formatCondition(c.Condition))
+	case *Filter:
+		return fmt.Sprintf("%s %s %s", c.projection().Name(), c.Operator.ToString(), formatValue(c.CompareValue))
+	case *NullFilter:
+		if c.IsNot {
+			return fmt.Sprintf("%s IS NOT NULL", c.projection().Name())
+		}
+
+		return fmt.Sprintf("%s IS NULL", c.projection().Name())
+	case *InFilter:
+		return fmt.Sprintf("%s IN (%s)", c.projection().Name(), strings.Join(Map(c.Values, formatValue), ", "))
+	case *LikeFilter:
+		if c.Escape != 0 {
+			return fmt.Sprintf("%s LIKE %s ESCAPE %s", c.projection().Name(), quoteString(c.Pattern), quoteString(string(c.Escape)))
+		}
+
+		return fmt.Sprintf("%s LIKE %s", c.projection().Name(), quoteString(c.Pattern))
+	case *ColumnComparison:
+		return fmt.Sprintf("%s %s %s", c.LeftColumn, c.Operator.ToString(), c.RightColumn)
+	case nil:
+		return ""
+	}
+
+	return fmt.Sprintf("%T", condition)
+}
+
+// Format a compared value for explain, values that are not numbers are quoted
+func formatValue(value Value) string {
+	if value.IsNull() {
+		return value.ToString()
+	}
+
+	if _, err := strconv.ParseFloat(value.String, 64); err == nil {
+		return value.String
+	}
+
+	return quoteString(value.String)
+}
+
+// Quote a string with single quotes for explain, quotes in the string are doubled
+func quoteString(s string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", "''"))
+}
+
+// Format sorters for explain, for example name ASC, COUNT(*) DESC
+func formatSorters(sorters []*Sorter) string {
+	return strings.Join(Map(sorters, func(sorter *Sorter) string {
+		if sorter.Direction == DIRECTION_DESCENDING {
+			return fmt.Sprintf("%s DESC", sorter.projection().Name())
+		}
+
+		return fmt.Sprintf("%s ASC", sorter.projection().Name())
+	}), ", ")
+}
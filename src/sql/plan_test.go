@@ -0,0 +1,110 @@
+package sql
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPlanIndex(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "items", Columns: []*Column{
+		{Name: "col1", Type: TYPE_INT, Values: NewValues("3", "1", "2")},
+		{Name: "col2", Type: TYPE_VARCHAR, Values: NewValues("c", "a", "b")},
+	}})
+
+	table, _ := database.Get("items")
+	table.addIndex(&Index{Name: "index", ColumnNames: []string{"col1"}})
+
+	tests := []struct {
+		query     string
+		operation string
+		detail    string
+	}{
+		{"SELECT * FROM items WHERE col1 > 1", "INDEX SCAN", ""},
+		{"SELECT * FROM items ORDER BY col1 ASC", "INDEX SCAN", "ordered by col1"},
+		{"SELECT * FROM items WHERE col2 = 'a'", "TABLE SCAN", ""},
+	}
+
+	for _, test := range tests {
+		description := explain(t, database, test.query)
+		for len(description.Inputs) > 0 {
+			description = description.Inputs[0]
+		}
+
+		if description.Operation != test.operation || description.Detail != test.detail {
+			t.Fatalf("wrong scan for %s, expected=%s %s, got=%s %s", test.query, test.operation, test.detail, description.Operation, description.Detail)
+		}
+	}
+
+	operation, _ := Parse(Tokenize([]byte("SELECT col2 FROM items WHERE col1 >= 1 ORDER BY col1 ASC LIMIT 2")))
+	data, err := operation.(*SelectOperation).getData(database)
+	if err != nil || !reflect.DeepEqual(data.Data, [][]Value{NewValues("a"), NewValues("b")}) {
+		t.Fatal("rows read from the index should be in the order of the index")
+	}
+}
+
+func TestPlanJoin(t *testing.T) {
+	database := NewDatabase("",
+		&Table{Name: "artists", Columns: []*Column{{Name: "id", Type: TYPE_INT, Values: NewValues("1", "2")}}},
+		&Table{Name: "albums", Columns: []*Column{{Name: "artist_id", Type: TYPE_INT, Values: NewValues("1", "1")}}},
+	)
+
+	description := explain(t, database, "SELECT * FROM artists a LEFT JOIN albums b ON a.id = b.artist_id WHERE a.id = 2 AND b.artist_id IS NULL")
+	if description.Inputs[0].Operation != "FILTER" || description.Inputs[0].Condition != "b.artist_id IS NULL" {
+		t.Fatal("condition on the right table of a left join should be filtered after the join")
+	}
+
+	join := description.Inputs[0].Inputs[0]
+	if join.Operation != "LEFT JOIN" || join.Inputs[0].Operation != "FILTER" || join.Inputs[0].Condition != "a.id = 2" {
+		t.Fatal("condition on the left table should be filtered before the join")
+	}
+}
+
+func TestPlanUnknownSortColumn(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "items", Columns: []*Column{
+		{Name: "col1", Type: TYPE_INT, Values: NewValues("3", "1", "2")},
+		{Name: "col2", Type: TYPE_VARCHAR, Values: NewValues("c", "a", "b")},
+	}})
+
+	tests := []struct {
+		query    string
+		position int
+	}{
+		{"SELECT * FROM items ORDER BY nope", 6},
+		{"SELECT * FROM items ORDER BY col1 ASC, nope DESC", 9},
+		{"SELECT col2, COUNT(*) FROM items GROUP BY col2 ORDER BY col1", 14},
+	}
+
+	for _, test := range tests {
+		operation, _ := Parse(Tokenize([]byte(test.query)))
+		_, err := operation.(*SelectOperation).getData(database)
+		if err == nil || GetError(err).Category != ERROR_NOT_FOUND || GetError(err).Position != test.position {
+			t.Fatalf("unknown sort column should have been found at token %d for %s, got %v", test.position, test.query, err)
+		}
+	}
+}
+
+func explain(t *testing.T, database *Database, query string) *PlanDescription {
+	operation, err := Parse(Tokenize([]byte("EXPLAIN " + query)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := operation.Call(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	description := &PlanDescription{}
+	json.Unmarshal(data, description)
+	return description
+}
+
+func selectData(t *testing.T, database *Database, query string) (*TableData, error) {
+	operation, err := Parse(Tokenize([]byte(query)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return operation.(*SelectOperation).getData(database)
+}
@@ -1,29 +1,51 @@
-package sql
-
-import (
-	"fmt"
-	"strings"
-)
-
-type SortDirection int
-
-const (
-	DIRECTION_ASCENDING  SortDirection = 1  // Ascending order
-	DIRECTION_DESCENDING SortDirection = -1 // Descending order
-)
-
-type Sorter struct {
-	ColumnName string        // Column name to sort by
-	Direction  SortDirection // Order of the sorting
-}
-
-func GetSortDirection(s string) (SortDirection, error) {
-	switch strings.ToUpper(s) {
-	case "ASC":
-		return DIRECTION_ASCENDING, nil
-	case "DESC":
-		return DIRECTION_DESCENDING, nil
-	}
-
-	return -1, fmt.Errorf("invalid sort direction %s", s)
-}
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SortDirection int
+
+const (
+	DIRECTION_ASCENDING  SortDirection = 1  // Ascending order
+	DIRECTION_DESCENDING SortDirection = -1 // Descending order
+)
+
+type Sorter struct {
+	ColumnName string            // Column name to sort by
+	Function   AggregateFunction // Aggregate function applied to the column, only used with group by
+	Direction  SortDirection     // Order of the sorting
+	Token      *Token            // Token of the column in the query, nil if the sorter was not parsed
+}
+
+// Get the column or aggregate function to sort by
+func (sorter *Sorter) projection() *Projection {
+	return &Projection{ColumnName: sorter.ColumnName, Function: sorter.Function, Token: sorter.Token}
+}
+
+// Get the columns of a table to sort by, unknown columns are errors located at the token of the sorter
+func getSortColumns(table *Table, sorters []*Sorter) ([]*Column, error) {
+	columns := []*Column{}
+	for _, sorter := range sorters {
+		col, err := table.getColumnByName(sorter.projection().Name())
+		if err != nil {
+			return nil, locateError(err, sorter.Token)
+		}
+
+		columns = append(columns, col)
+	}
+
+	return columns, nil
+}
+
+func GetSortDirection(s string) (SortDirection, error) {
+	switch strings.ToUpper(s) {
+	case "ASC":
+		return DIRECTION_ASCENDING, nil
+	case "DESC":
+		return DIRECTION_DESCENDING, nil
+	}
+
+	return -1, fmt.Errorf("invalid sort direction %s", s)
+}
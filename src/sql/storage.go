@@ -0,0 +1,186 @@
+package sql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Name of the manifest file in the database directory
+const MANIFEST_FILE_NAME = "manifest.json"
+
+// Lists the table files of a saved database.
+// The manifest is replaced only after every table file is written, so the files listed are always complete
+type Manifest struct {
+	Generation int             `json:"generation"` // Incremented on every save, used to name the table files
+	Sequence   int64           `json:"sequence"`   // Sequence of the last write-ahead log record included in the table files
+	Tables     []ManifestTable `json:"tables"`     // Saved tables in the order of the database
+}
+
+// A single table file in a manifest
+type ManifestTable struct {
+	Table   string   `json:"table"`             // Table name
+	File    string   `json:"file"`              // File name of the table in the database directory
+	Indexes []*Index `json:"indexes,omitempty"` // Indexes of the table, built when the table is read
+}
+
+// Read the manifest of a database directory, returns nil if the directory has no manifest
+func readManifest(rootPath string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(rootPath, MANIFEST_FILE_NAME))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	err = json.Unmarshal(data, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// Write the manifest of a database directory
+func writeManifest(rootPath string, manifest *Manifest) error {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(rootPath, MANIFEST_FILE_NAME), data)
+}
+
+// Get the names of the table files in a database directory that are not listed in the manifest.
+// Includes temporary files left behind by an interrupted save
+func getUnusedFiles(rootPath string, manifest *Manifest) ([]string, error) {
+	files, err := os.ReadDir(rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	unused := []string{}
+	for _, file := range files {
+		fileName := file.Name()
+		if fileName == MANIFEST_FILE_NAME || !slices.ContainsFunc([]string{TABLE_FILE_EXTENSION, ".json", ".tmp"}, func(extension string) bool { return strings.HasSuffix(fileName, extension) }) {
+			continue
+		}
+
+		if IsTrueForAny(manifest.Tables, func(table ManifestTable) bool { return table.File == fileName }) {
+			continue
+		}
+
+		unused = append(unused, fileName)
+	}
+
+	return unused, nil
+}
+
+// Read a table from a table file or from a json file saved by an older version
+func readTable(filePath string) (*Table, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var table *Table
+	if isLegacyFile(filePath) {
+		table, err = readLegacyTable(data)
+	} else {
+		table, err = decodeTable(data)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(filePath), err)
+	}
+
+	return table, nil
+}
+
+// Check if a table file is a json file saved by an older version
+func isLegacyFile(filePath string) bool {
+	return strings.HasSuffix(filePath, ".json")
+}
+
+// Read a table from a json file saved by an older version.
+// Older versions did not check the values against the types of the columns, a value that is not valid for the type fails the read
+// so that the file is kept until the value is fixed
+func readLegacyTable(data []byte) (*Table, error) {
+	table := &Table{}
+	err := json.Unmarshal(data, table)
+	if err != nil {
+		return nil, err
+	}
+
+	rowCount := table.getRowCount()
+	for _, col := range table.Columns {
+		if len(col.Values) != rowCount {
+			return nil, fmt.Errorf("column %s has %d values but the table has %d rows", col.Name, len(col.Values), rowCount)
+		}
+
+		col.Default, err = col.Type.Coerce(col.Default)
+		if err != nil {
+			return nil, fmt.Errorf("table %s, default value of column %s could not be read, %w", table.Name, col.Name, err)
+		}
+
+		for i, value := range col.Values {
+			col.Values[i], err = col.Type.Coerce(value)
+			if err != nil {
+				return nil, fmt.Errorf("table %s, column %s, row %d could not be read, %w", table.Name, col.Name, i+1, err)
+			}
+		}
+	}
+
+	return table, nil
+}
+
+// Write a file so that the file has either the old or the new content even if the program crashes.
+// The data is written to a temporary file, which is flushed to the disk and renamed over the file
+func writeFileAtomic(filePath string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), filePath)
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	return syncDirectory(filepath.Dir(filePath))
+}
+
+// Flush a directory to the disk so that renamed files are not lost in a crash.
+// Some platforms cannot sync directories, in which case nothing is done
+func syncDirectory(dirPath string) error {
+	dir, err := os.Open(dirPath)
+	if err != nil {
+		return err
+	}
+
+	defer dir.Close()
+	dir.Sync()
+	return nil
+}
@@ -1,189 +1,484 @@
-package sql
-
-import (
-	"fmt"
-	"slices"
-)
-
-// Represents a single table in the database
-type Table struct {
-	Name    string    `json:"table"`   // Table name
-	Columns []*Column `json:"columns"` // Table columns
-}
-
-// An object to return by get method
-type TableData struct {
-	Columns     []string   `json:"columns"`      // Table column name array
-	ColumnTypes []string   `json:"column_types"` // Table column type array
-	Data        [][]string `json:"data"`         // Table data, array of rows
-}
-
-type RowData struct {
-	ColName string
-	Value   string
-}
-
-type ColData struct {
-	ColName string
-	ColType ColumnType
-}
-
-type SortData struct {
-	Index int
-	Row   []string
-}
-
-// Insert data to a table
-func (table *Table) Insert(data []RowData) error {
-	for _, col := range table.Columns {
-		dataIndex := slices.IndexFunc(data, func(rowData RowData) bool { return rowData.ColName == col.Name })
-		if dataIndex != -1 {
-			value := data[dataIndex].Value
-			col.Values = append(col.Values, value)
-			continue
-		}
-
-		value, _ := col.Type.GetDefaultValue()
-		col.Values = append(col.Values, value)
-	}
-
-	return nil
-}
-
-// Get data from a table
-//   - columnNames define which columns to include, * get all.
-//   - filters define which rows to include
-//   - sorters defines the order of the rows
-func (table *Table) Get(columnNames []string, filters []*Filter, sorters []*Sorter) (*TableData, error) {
-	rowCount := len(table.Columns[0].Values)
-	columns := table.getColumns(columnNames)
-	sortData := []*SortData{}
-	data := &TableData{
-		Columns:     Map(columns, func(col *Column) string { return col.Name }),
-		ColumnTypes: Map(columns, func(col *Column) string { return col.Type.ToString() }),
-		Data:        [][]string{},
-	}
-
-	for rowIndex := 0; rowIndex < rowCount; rowIndex++ {
-		if !table.isRowIncludedInFilters(rowIndex, filters) {
-			continue
-		}
-
-		row := make([]string, len(columns))
-		for colIndex, col := range columns {
-			value := col.Values[rowIndex]
-			row[colIndex] = value
-		}
-
-		sortData = append(sortData, &SortData{Index: rowIndex, Row: row})
-	}
-
-	table.sort(sortData, sorters)
-	data.Data = Map(sortData, func(data *SortData) []string { return data.Row })
-	return data, nil
-}
-
-// Update values of the table
-func (table *Table) Update(data []RowData, filters []*Filter) error {
-	colCount := len(table.Columns)
-	rowCount := len(table.Columns[0].Values)
-
-	for rowIndex := 0; rowIndex < rowCount; rowIndex++ {
-		if !table.isRowIncludedInFilters(rowIndex, filters) {
-			continue
-		}
-
-		for colIndex := 0; colIndex < colCount; colIndex++ {
-			col := table.Columns[colIndex]
-			dataIndex := slices.IndexFunc(data, func(valData RowData) bool { return valData.ColName == col.Name })
-
-			if dataIndex != -1 {
-				newValue := data[dataIndex].Value
-				col.Values[rowIndex] = newValue
-			}
-		}
-	}
-
-	return nil
-}
-
-// Delete values from the table
-func (table *Table) Delete(filters []*Filter) error {
-	colCount := len(table.Columns)
-	rowCount := len(table.Columns[0].Values)
-
-	for rowIndex := rowCount - 1; rowIndex >= 0; rowIndex-- {
-		if !table.isRowIncludedInFilters(rowIndex, filters) {
-			continue
-		}
-
-		for colIndex := 0; colIndex < colCount; colIndex++ {
-			col := table.Columns[colIndex]
-			col.Values = slices.Delete(col.Values, rowIndex, rowIndex+1)
-		}
-	}
-
-	return nil
-}
-
-// Get a column by name
-func (table *Table) getColumnByName(colName string) (*Column, error) {
-	index := slices.IndexFunc(table.Columns, func(col *Column) bool { return col.Name == colName })
-	if index == -1 {
-		return nil, fmt.Errorf("no column was found: %s", colName)
-	}
-
-	return table.Columns[index], nil
-}
-
-// Get all columns by name array, if name array contains only a single asterisk all columns are returned
-func (table *Table) getColumns(columnNames []string) []*Column {
-	if len(columnNames) == 1 && columnNames[0] == "*" {
-		return table.Columns // SELECT ALL
-	}
-
-	columns := []*Column{}
-	for _, colName := range columnNames {
-		column, err := table.getColumnByName(colName)
-		if err != nil {
-			continue
-		}
-
-		columns = append(columns, column)
-	}
-
-	return columns
-}
-
-// Check if row is included in the filters
-func (table *Table) isRowIncludedInFilters(rowIndex int, filters []*Filter) bool {
-	for _, col := range table.Columns {
-		value := col.Values[rowIndex]
-		if IsTrueForAny(filters, func(filter *Filter) bool { return filter.ColumnName == col.Name && !filter.IsIncluded(value, col.Type) }) {
-			// ROW NOT INCLUDED IN THE FILTERS
-			return false
-		}
-	}
-
-	return true
-}
-
-func (table *Table) sort(data []*SortData, sorters []*Sorter) {
-	if len(sorters) <= 0 {
-		return
-	}
-
-	slices.SortFunc(data, func(a *SortData, b *SortData) int {
-		for _, sorter := range sorters {
-			colIndex := slices.IndexFunc(table.Columns, func(col *Column) bool { return col.Name == sorter.ColumnName })
-			col := table.Columns[colIndex]
-
-			diff := Compare(col.Type, col.Values[a.Index], col.Values[b.Index])
-			if diff != 0 {
-				return diff * int(sorter.Direction)
-			}
-		}
-
-		return 0
-	})
-}
+package sql
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Represents a single table in the database
+type Table struct {
+	Name    string       `json:"table"`   // Table name
+	Columns []*Column    `json:"columns"` // Table columns
+	mutex   sync.RWMutex // Guards the values of the table, locked by the database while an operation is called
+	Indexes []*Index     `json:"-"` // Table indexes, saved in the manifest of the database
+	changes []*Change    // Changes made to the table that are not yet recorded by the database
+}
+
+// An object to return by get method
+type TableData struct {
+	Columns     []string  `json:"columns"`      // Table column name array
+	ColumnTypes []string  `json:"column_types"` // Table column type array
+	Data        [][]Value `json:"data"`         // Table data, array of rows, null values are written as null
+}
+
+type RowData struct {
+	ColName string
+	Value   Value
+	Token   *Token // Token of the value in the query, nil if the value was not parsed
+}
+
+type ColData struct {
+	ColName    string
+	ColType    ColumnType
+	PrimaryKey bool
+	NotNull    bool
+	Unique     bool
+	Default    Value
+}
+
+type SortData struct {
+	Index int
+	Row   []Value
+}
+
+// Insert data to a table, columns missing from the data get their default value.
+// Values are converted to the types of the columns.
+// Nothing is inserted if a value has an invalid type or the row breaks a constraint of a column
+func (table *Table) Insert(data []RowData) error {
+	return table.InsertRows([][]RowData{data})
+}
+
+// Insert many rows to a table at once, see Insert.
+// Nothing is inserted if any of the rows cannot be inserted
+func (table *Table) InsertRows(rows [][]RowData) error {
+	newRows := [][]Value{}
+	for _, data := range rows {
+		row, err := table.createRow(data)
+		if err != nil {
+			return err
+		}
+
+		newRows = append(newRows, row)
+	}
+
+	for colIndex, col := range table.Columns {
+		if err := col.checkUnique(col.Values, Map(newRows, func(row []Value) Value { return row[colIndex] })); err != nil {
+			return err
+		}
+	}
+
+	if err := table.checkIndexes(newRows); err != nil {
+		return err
+	}
+
+	rowCount := table.getRowCount()
+	for colIndex, col := range table.Columns {
+		for _, row := range newRows {
+			col.Values = append(col.Values, row[colIndex])
+		}
+	}
+
+	for _, index := range table.Indexes {
+		for rowIndex := rowCount; rowIndex < table.getRowCount(); rowIndex++ {
+			index.tree.add(table.getIndexKey(index, rowIndex), rowIndex)
+		}
+	}
+
+	table.changes = append(table.changes, &Change{Type: CHANGE_INSERT, Table: table.Name, Rows: newRows, undo: func() {
+		for _, col := range table.Columns {
+			col.Values = col.Values[:rowCount]
+		}
+
+		table.rebuildIndexes()
+	}})
+
+	return nil
+}
+
+// Create a row of values in the order of the columns from the data.
+// Values are converted to the types of the columns and checked against the not null constraints
+func (table *Table) createRow(data []RowData) ([]Value, error) {
+	for _, rowData := range data {
+		if _, err := table.getColumnByName(rowData.ColName); err != nil {
+			return nil, err
+		}
+	}
+
+	row := make([]Value, len(table.Columns))
+	for colIndex, col := range table.Columns {
+		row[colIndex] = col.Default
+		dataIndex := slices.IndexFunc(data, func(rowData RowData) bool { return rowData.ColName == col.Name })
+		if dataIndex != -1 {
+			value, err := col.coerce(data[dataIndex].Value)
+			if err != nil {
+				return nil, locateError(err, data[dataIndex].Token)
+			}
+
+			row[colIndex] = value
+		}
+
+		if err := col.checkNull(row[colIndex]); err != nil {
+			return nil, err
+		}
+	}
+
+	return row, nil
+}
+
+// Split rows to groups that have equal values in the group columns.
+// Without group columns every row belongs to a single group
+func (table *Table) groupRowIndexes(rowIndexes []int, groupColumns []*Column) [][]int {
+	if len(groupColumns) == 0 {
+		return [][]int{rowIndexes}
+	}
+
+	compare := func(a int, b int) int {
+		for _, col := range groupColumns {
+			diff := Compare(col.Type, col.Values[a], col.Values[b])
+			if diff != 0 {
+				return diff
+			}
+		}
+
+		return 0
+	}
+
+	slices.SortStableFunc(rowIndexes, compare)
+	groups := [][]int{}
+	for i, rowIndex := range rowIndexes {
+		if i == 0 || compare(rowIndexes[i-1], rowIndex) != 0 {
+			groups = append(groups, []int{})
+		}
+
+		groups[len(groups)-1] = append(groups[len(groups)-1], rowIndex)
+	}
+
+	return groups
+}
+
+// Create a table with a row for each group.
+// The table contains the group columns and a column for each distinct aggregate function
+func (table *Table) aggregateGroups(groups [][]int, groupColumns []*Column, aggregates []*Projection) (*Table, error) {
+	result := &Table{Name: table.Name}
+	for _, col := range groupColumns {
+		result.Columns = append(result.Columns, &Column{
+			Name:   col.Name,
+			Type:   col.Type,
+			Values: Map(groups, func(rowIndexes []int) Value { return col.Values[rowIndexes[0]] }),
+		})
+	}
+
+	for _, aggregate := range aggregates {
+		if !aggregate.IsAggregate() {
+			continue
+		}
+
+		if _, err := result.getColumnByName(aggregate.Name()); err == nil {
+			continue
+		}
+
+		t, err := aggregate.getType(table)
+		if err != nil {
+			return nil, locateError(err, aggregate.Token)
+		}
+
+		col := &Column{Name: aggregate.Name(), Type: t, Values: []Value{}}
+		for _, rowIndexes := range groups {
+			value, _, err := aggregate.aggregate(table, rowIndexes)
+			if err != nil {
+				return nil, err
+			}
+
+			col.Values = append(col.Values, value)
+		}
+
+		result.Columns = append(result.Columns, col)
+	}
+
+	return result, nil
+}
+
+// Update values of the table.
+// Every assignment is evaluated against the values of the row before the update and converted to the type of the column.
+// Nothing is updated if any new value has an invalid type or any updated row breaks a constraint of a column
+func (table *Table) Update(assignments []*Assignment, condition Condition) error {
+	condition, err := bindCondition(table, condition)
+	if err != nil {
+		return err
+	}
+
+	columns := []*Column{}
+	for _, assignment := range assignments {
+		col, err := table.getColumnByName(assignment.ColumnName)
+		if err != nil {
+			return locateError(err, assignment.Token)
+		}
+
+		columns = append(columns, col)
+	}
+
+	rowIndexes := []int{}
+	newValues := [][]Value{}
+
+	for _, rowIndex := range table.getCandidateRows(condition) {
+		if !table.isRowIncluded(rowIndex, condition) {
+			continue
+		}
+
+		row := make([]Value, len(assignments))
+		for i, assignment := range assignments {
+			value, _, err := assignment.Expression.Evaluate(table, rowIndex)
+			if err != nil {
+				return err
+			}
+
+			row[i], err = columns[i].coerce(value)
+			if err != nil {
+				return locateError(err, assignment.Token)
+			}
+		}
+
+		rowIndexes = append(rowIndexes, rowIndex)
+		newValues = append(newValues, row)
+	}
+
+	updated := make(map[int]struct{}, len(rowIndexes))
+	for _, rowIndex := range rowIndexes {
+		updated[rowIndex] = struct{}{}
+	}
+
+	for colIndex, col := range columns {
+		values := Map(newValues, func(row []Value) Value { return row[colIndex] })
+		for _, value := range values {
+			if err := col.checkNull(value); err != nil {
+				return err
+			}
+		}
+
+		if !col.IsUnique() {
+			continue
+		}
+
+		// ONLY THE VALUES OF THE ROWS THAT ARE NOT UPDATED ARE KEPT
+		kept := make([]Value, 0, len(col.Values))
+		for rowIndex, value := range col.Values {
+			if _, found := updated[rowIndex]; !found {
+				kept = append(kept, value)
+			}
+		}
+
+		if err := col.checkUnique(kept, values); err != nil {
+			return err
+		}
+	}
+
+	if err := table.checkIndexesOnUpdate(rowIndexes, columns, newValues); err != nil {
+		return err
+	}
+
+	indexes := table.getIndexesOfColumns(columns)
+	for _, index := range indexes {
+		for _, rowIndex := range rowIndexes {
+			index.tree.remove(table.getIndexKey(index, rowIndex), rowIndex)
+		}
+	}
+
+	oldValues := Map(rowIndexes, func(rowIndex int) []Value {
+		return Map(columns, func(col *Column) Value { return col.Values[rowIndex] })
+	})
+
+	for i, rowIndex := range rowIndexes {
+		for colIndex, col := range columns {
+			col.Values[rowIndex] = newValues[i][colIndex]
+		}
+	}
+
+	for _, index := range indexes {
+		for _, rowIndex := range rowIndexes {
+			index.tree.add(table.getIndexKey(index, rowIndex), rowIndex)
+		}
+	}
+
+	if len(rowIndexes) > 0 {
+		columnNames := Map(columns, func(col *Column) string { return col.Name })
+		table.changes = append(table.changes, &Change{Type: CHANGE_UPDATE, Table: table.Name, ColumnNames: columnNames, Indexes: rowIndexes, Rows: newValues, undo: func() {
+			for i, rowIndex := range rowIndexes {
+				for colIndex, col := range columns {
+					col.Values[rowIndex] = oldValues[i][colIndex]
+				}
+			}
+
+			table.rebuildIndexes()
+		}})
+	}
+
+	return nil
+}
+
+// Delete values from the table
+func (table *Table) Delete(condition Condition) error {
+	condition, err := bindCondition(table, condition)
+	if err != nil {
+		return err
+	}
+
+	colCount := len(table.Columns)
+	candidates := table.getCandidateRows(condition)
+	rowIndexes := []int{}
+	deletedRows := [][]Value{}
+
+	for i := len(candidates) - 1; i >= 0; i-- {
+		rowIndex := candidates[i]
+		if !table.isRowIncluded(rowIndex, condition) {
+			continue
+		}
+
+		for _, index := range table.Indexes {
+			index.tree.remove(table.getIndexKey(index, rowIndex), rowIndex)
+		}
+
+		deletedRows = append(deletedRows, Map(table.Columns, func(col *Column) Value { return col.Values[rowIndex] }))
+		for colIndex := 0; colIndex < colCount; colIndex++ {
+			col := table.Columns[colIndex]
+			col.Values = slices.Delete(col.Values, rowIndex, rowIndex+1)
+		}
+
+		rowIndexes = append(rowIndexes, rowIndex)
+	}
+
+	if len(rowIndexes) > 0 {
+		shiftedRows := slices.Clone(rowIndexes)
+		slices.Reverse(shiftedRows)
+		for _, index := range table.Indexes {
+			index.tree.shift(shiftedRows)
+		}
+
+		table.changes = append(table.changes, &Change{Type: CHANGE_DELETE, Table: table.Name, Indexes: rowIndexes, undo: func() {
+			// ROWS WERE DELETED FROM THE END, SO THEY ARE INSERTED BACK FROM THE START
+			for i := len(rowIndexes) - 1; i >= 0; i-- {
+				for colIndex, col := range table.Columns {
+					col.Values = slices.Insert(col.Values, rowIndexes[i], deletedRows[i][colIndex])
+				}
+			}
+
+			table.rebuildIndexes()
+		}})
+	}
+
+	return nil
+}
+
+// Get a column by name.
+// The name can be qualified with the table name, for example table.column.
+// Columns of a joined table are qualified, those can also be found by the unqualified name if it is not ambiguous
+func (table *Table) getColumnByName(colName string) (*Column, error) {
+	index := slices.IndexFunc(table.Columns, func(col *Column) bool { return col.Name == colName })
+	if index != -1 {
+		return table.Columns[index], nil
+	}
+
+	if name, found := strings.CutPrefix(colName, table.Name+"."); found && table.Name != "" {
+		index = slices.IndexFunc(table.Columns, func(col *Column) bool { return col.Name == name })
+		if index != -1 {
+			return table.Columns[index], nil
+		}
+	}
+
+	var match *Column
+	for _, col := range table.Columns {
+		if !strings.HasSuffix(col.Name, "."+colName) {
+			continue
+		}
+
+		if match != nil {
+			return nil, newError(ERROR_SYNTAX, "column name is ambiguous: %s", colName)
+		}
+
+		match = col
+	}
+
+	if match == nil {
+		return nil, newError(ERROR_NOT_FOUND, "no column was found: %s", colName)
+	}
+
+	return match, nil
+}
+
+// Get all columns by name array, if name array contains only a single asterisk all columns are returned
+func (table *Table) getColumns(columnNames []string) ([]*Column, error) {
+	if len(columnNames) == 1 && columnNames[0] == "*" {
+		return table.Columns, nil // SELECT ALL
+	}
+
+	columns := []*Column{}
+	for _, colName := range columnNames {
+		column, err := table.getColumnByName(colName)
+		if err != nil {
+			return nil, err
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// Create a copy of the table that does not share any values with the original table
+func (table *Table) clone() *Table {
+	clone := &Table{
+		Name: table.Name,
+		Columns: Map(table.Columns, func(col *Column) *Column {
+			clone := *col
+			clone.Values = slices.Clone(col.Values)
+			return &clone
+		}),
+		Indexes: Map(table.Indexes, (*Index).definition),
+	}
+
+	for _, index := range clone.Indexes {
+		clone.buildIndex(index)
+	}
+
+	return clone
+}
+
+// Get the number of rows in the table
+func (table *Table) getRowCount() int {
+	if len(table.Columns) == 0 {
+		return 0
+	}
+
+	return len(table.Columns[0].Values)
+}
+
+// Check if row is included in the condition, nil condition includes every row.
+// Rows for which the condition is unknown are not included
+func (table *Table) isRowIncluded(rowIndex int, condition Condition) bool {
+	if condition == nil {
+		return true
+	}
+
+	return condition.Evaluate(table, rowIndex) == TRUTH_TRUE
+}
+
+// Sort rows by the sorters, the columns of the sorters must exist
+func (table *Table) sort(data []*SortData, sorters []*Sorter) error {
+	columns, err := getSortColumns(table, sorters)
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(data, func(a *SortData, b *SortData) int {
+		for i, col := range columns {
+			diff := Compare(col.Type, col.Values[a.Index], col.Values[b.Index])
+			if diff != 0 {
+				return diff * int(sorters[i].Direction)
+			}
+		}
+
+		return 0
+	})
+
+	return nil
+}
@@ -1,37 +1,141 @@
-package sql
-
-import (
-	"testing"
-)
-
-func TestTableInsert(t *testing.T) {
-	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT}, {Name: "col2", Type: TYPE_VARCHAR}}}
-	err := table.Insert([]RowData{{ColName: "col1", Value: "1"}, {ColName: "col2", Value: "val2"}})
-	if err != nil {
-		t.Fatal("insert returned an error but should not have")
-	}
-}
-
-func TestTableGet(t *testing.T) {
-	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: []string{"1", "2", "3"}}}}
-	_, err := table.Get([]string{"col1"}, []*Filter{}, []*Sorter{})
-	if err != nil {
-		t.Fatal("get returned an error but should not have")
-	}
-}
-
-func TestTableUpdate(t *testing.T) {
-	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: []string{"1", "2", "3"}}}}
-	err := table.Update([]RowData{{ColName: "col1", Value: "5"}}, []*Filter{})
-	if err != nil {
-		t.Fatal("update returned an error but should not have")
-	}
-}
-
-func TestTableDelete(t *testing.T) {
-	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: []string{"1", "2", "3"}}}}
-	err := table.Delete([]*Filter{})
-	if err != nil {
-		t.Fatal("delete returned an error but should not have")
-	}
-}
+package sql
+
+import (
+	"testing"
+)
+
+func TestTableInsert(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT}, {Name: "col2", Type: TYPE_VARCHAR}}}
+	err := table.Insert([]RowData{{ColName: "col1", Value: NewValue("1")}, {ColName: "col2", Value: NewValue("val2")}})
+	if err != nil {
+		t.Fatal("insert returned an error but should not have")
+	}
+}
+
+func TestTableInsertConstraints(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT, PrimaryKey: true}, {Name: "col2", Type: TYPE_VARCHAR, Default: NewValue("def")}}}
+	err := table.Insert([]RowData{{ColName: "col1", Value: NewValue("1")}})
+	if err != nil || table.Columns[1].Values[0].String != "def" {
+		t.Fatal("insert should have used the default value")
+	}
+
+	err = table.Insert([]RowData{{ColName: "col1", Value: NewValue("1")}})
+	if err == nil {
+		t.Fatal("error was not thrown for a duplicate primary key but should have")
+	}
+
+	err = table.Insert([]RowData{{ColName: "col2", Value: NewValue("val2")}})
+	if err == nil || len(table.Columns[1].Values) != 1 {
+		t.Fatal("error was not thrown for a null primary key but should have")
+	}
+}
+
+func TestTableInsertRows(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT, Unique: true}}}
+	err := table.InsertRows([][]RowData{{{ColName: "col1", Value: NewValue("1")}}, {{ColName: "col1", Value: NewValue("2")}}})
+	if err != nil || len(table.Columns[0].Values) != 2 {
+		t.Fatal("insert rows should have inserted two rows")
+	}
+
+	err = table.InsertRows([][]RowData{{{ColName: "col1", Value: NewValue("3")}}, {{ColName: "col1", Value: NewValue("3")}}})
+	if err == nil || len(table.Columns[0].Values) != 2 {
+		t.Fatal("insert rows should have failed without inserting any rows")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "t", Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: NewValues("1", "2", "3")}}})
+	_, err := selectData(t, database, "SELECT col1 FROM t")
+	if err != nil {
+		t.Fatal("select returned an error but should not have")
+	}
+}
+
+func TestSelectLimit(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "t", Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: NewValues("3", "1", "2", "5", "4")}}})
+	data, err := selectData(t, database, "SELECT col1 FROM t ORDER BY col1 LIMIT 2 OFFSET 1")
+	if err != nil || len(data.Data) != 2 || data.Data[0][0].String != "2" || data.Data[1][0].String != "3" {
+		t.Fatalf("wrong rows returned, expected [[2] [3]], got %v", data.Data)
+	}
+
+	data, err = selectData(t, database, "SELECT col1 FROM t OFFSET 4")
+	if err != nil || len(data.Data) != 1 || data.Data[0][0].String != "4" {
+		t.Fatalf("wrong rows returned, expected [[4]], got %v", data.Data)
+	}
+}
+
+func TestTableUpdate(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: NewValues("1", "2", "3")}}}
+	err := table.Update([]*Assignment{{ColumnName: "col1", Expression: &LiteralExpression{Value: NewValue("5")}}}, nil)
+	if err != nil {
+		t.Fatal("update returned an error but should not have")
+	}
+}
+
+func TestTableUpdateExpression(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: NewValues("1", "2", "3")}}}
+	expression := &ArithmeticExpression{Operator: ARITHMETIC_ADD, Left: &IdentifierExpression{Name: "col1"}, Right: &LiteralExpression{Value: NewValue("1")}}
+	err := table.Update([]*Assignment{{ColumnName: "col1", Expression: expression}}, nil)
+	if err != nil || table.Columns[0].Values[0].String != "2" || table.Columns[0].Values[2].String != "4" {
+		t.Fatal("update did not evaluate the expression against the row")
+	}
+}
+
+func TestTableUpdateStringLiteral(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: NewValues("1")}}}
+	operation, err := Parse(Tokenize([]byte("UPDATE t SET col1 = '1' + '2'")))
+	if err != nil {
+		t.Fatalf("parse returned an error but should not have: %s", err.Error())
+	}
+
+	err = table.Update(operation.(*UpdateOperation).Assignments, nil)
+	if err == nil || GetError(err).Category != ERROR_TYPE || table.Columns[0].Values[0].String != "1" {
+		t.Fatalf("arithmetic on string literals should have returned a type error, got %v", err)
+	}
+
+	operation, _ = Parse(Tokenize([]byte("UPDATE t SET col1 = '3'")))
+	err = table.Update(operation.(*UpdateOperation).Assignments, nil)
+	if err != nil || table.Columns[0].Values[0].String != "3" {
+		t.Fatal("string literal should have been converted to the column type")
+	}
+}
+
+func TestTableUpdateUnique(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT, Unique: true, Values: NewValues("1", "2", "3")}}}
+	expression := &ArithmeticExpression{Operator: ARITHMETIC_ADD, Left: &IdentifierExpression{Name: "col1"}, Right: &LiteralExpression{Value: NewValue("1")}}
+	err := table.Update([]*Assignment{{ColumnName: "col1", Expression: expression}}, nil)
+	if err != nil || table.Columns[0].Values[0].String != "2" || table.Columns[0].Values[2].String != "4" {
+		t.Fatalf("update should have replaced every value of the unique column, got %v", err)
+	}
+
+	err = table.Update([]*Assignment{{ColumnName: "col1", Expression: &LiteralExpression{Value: NewValue("5")}}}, &Filter{ColumnName: "col1", Operator: GREATER, CompareValue: NewValue("2")})
+	if err == nil || GetError(err).Category != ERROR_CONSTRAINT || table.Columns[0].Values[1].String != "3" {
+		t.Fatal("update should have failed for duplicate new values")
+	}
+
+	err = table.Update([]*Assignment{{ColumnName: "col1", Expression: &LiteralExpression{Value: NewValue("2")}}}, &Filter{ColumnName: "col1", Operator: EQUAL, CompareValue: NewValue("4")})
+	if err == nil || GetError(err).Category != ERROR_CONSTRAINT || table.Columns[0].Values[2].String != "4" {
+		t.Fatal("update should have failed for a value of a row that is not updated")
+	}
+}
+
+func TestTableInsertUniqueFloat(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_FLOAT, Unique: true}}}
+	err := table.InsertRows([][]RowData{{{ColName: "col1", Value: NewValue("0")}}, {{ColName: "col1"}}, {{ColName: "col1"}}})
+	if err != nil || len(table.Columns[0].Values) != 3 {
+		t.Fatal("insert rows should have inserted a zero and two null values")
+	}
+
+	err = table.Insert([]RowData{{ColName: "col1", Value: NewValue("-0")}})
+	if err == nil || len(table.Columns[0].Values) != 3 {
+		t.Fatal("negative zero should have been a duplicate of zero")
+	}
+}
+
+func TestTableDelete(t *testing.T) {
+	table := &Table{Columns: []*Column{{Name: "col1", Type: TYPE_INT, Values: NewValues("1", "2", "3")}}}
+	err := table.Delete(nil)
+	if err != nil {
+		t.Fatal("delete returned an error but should not have")
+	}
+}
@@ -0,0 +1,290 @@
+package sql
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Time a transaction can stay unused before it is rolled back
+const TRANSACTION_TIMEOUT = 5 * time.Minute
+
+// Enum to represent a transaction statement, values are prefixed with TRANSACTION.
+type TransactionStatement int
+
+const (
+	TRANSACTION_BEGIN    TransactionStatement = 0 // Starts a new transaction
+	TRANSACTION_COMMIT   TransactionStatement = 1 // Makes the changes of a transaction visible and saves them
+	TRANSACTION_ROLLBACK TransactionStatement = 2 // Discards the changes of a transaction
+)
+
+// Get a transaction statement as a string
+func (statement TransactionStatement) ToString() string {
+	switch statement {
+	case TRANSACTION_BEGIN:
+		return "BEGIN"
+	case TRANSACTION_COMMIT:
+		return "COMMIT"
+	case TRANSACTION_ROLLBACK:
+		return "ROLLBACK"
+	}
+
+	return ""
+}
+
+// Sql transaction operation, for beginning, committing and rolling back transactions.
+// The operation must be executed with a transaction manager
+type TransactionOperation struct {
+	Statement TransactionStatement
+}
+
+// Transaction operation execute method, always fails because transactions are handled by a transaction manager
+func (operation *TransactionOperation) Call(database *Database) ([]byte, error) {
+	return nil, fmt.Errorf("%s cannot be executed without a transaction manager", operation.Statement.ToString())
+}
+
+// Represents a single transaction.
+// Operations in the transaction are called on a copy of the database, which replaces the database on commit
+type Transaction struct {
+	Id       string    // Transaction identifier
+	database *Database // Copy of the database with the changes made in the transaction
+	version  int64     // Version of the database when the transaction was started
+	lastUsed time.Time // Time the transaction was started or last used, see TRANSACTION_TIMEOUT
+}
+
+// An object to return when a transaction is started
+type TransactionData struct {
+	TransactionId string `json:"transaction_id"`
+}
+
+// Keeps track of the open transactions of a database
+type TransactionManager struct {
+	database     *Database               // Database the transactions are committed to
+	transactions map[string]*Transaction // Open transactions by id
+	timeout      time.Duration           // Time a transaction can stay unused before it is rolled back
+	mutex        sync.Mutex              // Guards the open transactions
+}
+
+// Create a new transaction manager for a database.
+// Transactions unused for TRANSACTION_TIMEOUT are rolled back, see RemoveExpired
+func NewTransactionManager(database *Database) *TransactionManager {
+	return &TransactionManager{
+		database:     database,
+		transactions: map[string]*Transaction{},
+		timeout:      TRANSACTION_TIMEOUT,
+	}
+}
+
+// Execute an operation, the operation is part of a transaction if transactionId is not empty.
+// Returns the result of the operation and the id of the transaction the next operations belong to,
+// which is empty after a commit or a rollback
+func (manager *TransactionManager) Execute(operation Operation, transactionId string) ([]byte, string, error) {
+	if transactionOperation, ok := operation.(*TransactionOperation); ok {
+		return manager.executeStatement(transactionOperation.Statement, transactionId)
+	}
+
+	if transactionId == "" {
+		result, err := operation.Call(manager.database)
+		return result, "", err
+	}
+
+	transaction, err := manager.Get(transactionId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result, err := operation.Call(transaction.database)
+	return result, transaction.Id, err
+}
+
+// Execute the operations of a script in order, the transaction id returned by an operation is used for the next operation.
+// Execution stops at the first failing operation and the changes of the previous operations are kept.
+// An atomic script is executed in a new transaction that is committed after the last operation and rolled back if an operation fails,
+// so either all or none of the changes are saved. Atomic scripts cannot be executed in a transaction or contain transaction statements.
+// Returns the results of the executed operations and the id of the transaction the next operations belong to,
+// errors have the number of the failed statement
+func (manager *TransactionManager) ExecuteScript(operations []Operation, transactionId string, atomic bool) ([][]byte, string, error) {
+	if atomic {
+		return manager.executeAtomic(operations, transactionId)
+	}
+
+	results := [][]byte{}
+	for i, operation := range operations {
+		result, id, err := manager.Execute(operation, transactionId)
+		transactionId = id
+		if err != nil {
+			return results, transactionId, getStatementError(err, i+1, 0)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, transactionId, nil
+}
+
+// Execute the operations of a script in a new transaction, none of the changes are saved if an operation fails
+func (manager *TransactionManager) executeAtomic(operations []Operation, transactionId string) ([][]byte, string, error) {
+	if transactionId != "" {
+		return nil, transactionId, newError(ERROR_CONSTRAINT, "atomic script cannot be executed in a transaction: %s", transactionId)
+	}
+
+	for i, operation := range operations {
+		if _, ok := operation.(*TransactionOperation); ok {
+			return nil, "", getStatementError(newError(ERROR_SYNTAX, "transaction statements cannot be used in an atomic script"), i+1, 0)
+		}
+	}
+
+	transaction, err := manager.Begin()
+	if err != nil {
+		return nil, "", err
+	}
+
+	results, _, err := manager.ExecuteScript(operations, transaction.Id, false)
+	if err != nil {
+		manager.Rollback(transaction.Id)
+		return nil, "", err
+	}
+
+	err = manager.Commit(transaction.Id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return results, "", nil
+}
+
+// Execute a transaction statement
+func (manager *TransactionManager) executeStatement(statement TransactionStatement, transactionId string) ([]byte, string, error) {
+	switch statement {
+	case TRANSACTION_BEGIN:
+		if transactionId != "" {
+			return nil, transactionId, newError(ERROR_CONSTRAINT, "transaction already in progress: %s", transactionId)
+		}
+
+		transaction, err := manager.Begin()
+		if err != nil {
+			return nil, "", err
+		}
+
+		result, err := json.Marshal(&TransactionData{TransactionId: transaction.Id})
+		return result, transaction.Id, err
+	case TRANSACTION_COMMIT:
+		return nil, "", manager.Commit(transactionId)
+	case TRANSACTION_ROLLBACK:
+		return nil, "", manager.Rollback(transactionId)
+	}
+
+	return nil, transactionId, fmt.Errorf("invalid transaction statement")
+}
+
+// Start a new transaction with a copy of the database, expired transactions are rolled back first
+func (manager *TransactionManager) Begin() (*Transaction, error) {
+	manager.RemoveExpired()
+
+	bytes := make([]byte, 16)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := manager.database.clone()
+	transaction := &Transaction{
+		Id:       hex.EncodeToString(bytes),
+		database: clone,
+		version:  clone.version.Load(),
+		lastUsed: time.Now(),
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	manager.transactions[transaction.Id] = transaction
+	return transaction, nil
+}
+
+// Get an open transaction by id and mark it used
+func (manager *TransactionManager) Get(transactionId string) (*Transaction, error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	transaction, ok := manager.transactions[transactionId]
+	if !ok {
+		return nil, newError(ERROR_NOT_FOUND, "transaction not found: %s", transactionId)
+	}
+
+	if manager.isExpired(transaction, time.Now()) {
+		delete(manager.transactions, transactionId)
+		return nil, newError(ERROR_NOT_FOUND, "transaction expired: %s", transactionId)
+	}
+
+	transaction.lastUsed = time.Now()
+	return transaction, nil
+}
+
+// Make the changes of a transaction visible in the database and save them.
+// The transaction is closed even if the commit fails.
+// Commit fails if a table changed in the transaction was changed in the database after the transaction was started,
+// changes to other tables do not conflict. Tables only read in the transaction are not checked
+func (manager *TransactionManager) Commit(transactionId string) error {
+	transaction, err := manager.remove(transactionId)
+	if err != nil {
+		return err
+	}
+
+	if transaction.database.version.Load() == transaction.version {
+		return nil
+	}
+
+	err = manager.database.replace(transaction.database, transaction.version)
+	if err != nil {
+		return fmt.Errorf("transaction %s could not be committed, %w", transactionId, err)
+	}
+
+	return nil
+}
+
+// Discard the changes of a transaction
+func (manager *TransactionManager) Rollback(transactionId string) error {
+	_, err := manager.remove(transactionId)
+	return err
+}
+
+// Roll back the transactions unused for longer than the timeout, so their copies of the database are released.
+// Called when a transaction is started, a server should also call it periodically
+func (manager *TransactionManager) RemoveExpired() {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	now := time.Now()
+	for id, transaction := range manager.transactions {
+		if manager.isExpired(transaction, now) {
+			delete(manager.transactions, id)
+		}
+	}
+}
+
+// Close a transaction
+func (manager *TransactionManager) remove(transactionId string) (*Transaction, error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	transaction, ok := manager.transactions[transactionId]
+	if !ok {
+		return nil, newError(ERROR_NOT_FOUND, "transaction not found: %s", transactionId)
+	}
+
+	delete(manager.transactions, transactionId)
+	if manager.isExpired(transaction, time.Now()) {
+		return nil, newError(ERROR_NOT_FOUND, "transaction expired: %s", transactionId)
+	}
+
+	return transaction, nil
+}
+
+// Check if a transaction was unused for longer than the timeout
+func (manager *TransactionManager) isExpired(transaction *Transaction, now time.Time) bool {
+	return now.Sub(transaction.lastUsed) > manager.timeout
+}
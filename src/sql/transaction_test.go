@@ -0,0 +1,146 @@
+package sql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransactionCommit(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "table", Columns: []*Column{{Name: "col1", Type: TYPE_INT}}})
+	manager := NewTransactionManager(database)
+	transaction, err := manager.Begin()
+	if err != nil {
+		t.Fatal("begin returned an error but should not have")
+	}
+
+	_, _, err = manager.Execute(&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("1")}}, transaction.Id)
+	if err != nil || len(database.tables[0].Columns[0].Values) != 0 {
+		t.Fatal("insert in a transaction should not be visible before commit")
+	}
+
+	err = manager.Commit(transaction.Id)
+	if err != nil || len(database.tables[0].Columns[0].Values) != 1 {
+		t.Fatal("insert in a transaction should be visible after commit")
+	}
+
+	_, _, err = manager.Execute(&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("2")}}, transaction.Id)
+	if err == nil {
+		t.Fatal("error was not thrown for a closed transaction but should have")
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "table", Columns: []*Column{{Name: "col1", Type: TYPE_INT}}})
+	manager := NewTransactionManager(database)
+	transaction, _ := manager.Begin()
+	manager.Execute(&DropOperation{TableName: "table"}, transaction.Id)
+
+	err := manager.Rollback(transaction.Id)
+	if err != nil || len(database.tables) != 1 {
+		t.Fatal("rollback should have discarded the changes of the transaction")
+	}
+}
+
+func TestTransactionConflict(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "table", Columns: []*Column{{Name: "col1", Type: TYPE_INT}}})
+	manager := NewTransactionManager(database)
+	transaction, _ := manager.Begin()
+	manager.Execute(&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("1")}}, transaction.Id)
+	manager.Execute(&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("2")}}, "")
+
+	err := manager.Commit(transaction.Id)
+	if err == nil || len(database.tables[0].Columns[0].Values) != 1 {
+		t.Fatal("error was not thrown for a conflicting commit but should have")
+	}
+}
+
+func TestTransactionUnrelatedTable(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "table1", Columns: []*Column{{Name: "col1", Type: TYPE_INT}}}, &Table{Name: "table2", Columns: []*Column{{Name: "col1", Type: TYPE_INT}}})
+	manager := NewTransactionManager(database)
+	transaction, _ := manager.Begin()
+	manager.Execute(&InsertOperation{TableName: "table1", Rows: [][]Value{NewValues("1")}}, transaction.Id)
+	manager.Execute(&InsertOperation{TableName: "table2", Rows: [][]Value{NewValues("2")}}, "")
+
+	err := manager.Commit(transaction.Id)
+	if err != nil {
+		t.Fatal("change to an unrelated table should not have failed the commit")
+	}
+
+	if len(database.tables[0].Columns[0].Values) != 1 || len(database.tables[1].Columns[0].Values) != 1 {
+		t.Fatal("changes of the transaction and the unrelated table should both have been kept")
+	}
+}
+
+func TestTransactionExpired(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "table", Columns: []*Column{{Name: "col1", Type: TYPE_INT}}})
+	manager := NewTransactionManager(database)
+	expired, _ := manager.Begin()
+	active, _ := manager.Begin()
+	expired.lastUsed = time.Now().Add(-TRANSACTION_TIMEOUT - time.Second)
+
+	_, _, err := manager.Execute(&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("1")}}, expired.Id)
+	if err == nil || GetError(err).Category != ERROR_NOT_FOUND {
+		t.Fatal("error was not thrown for an expired transaction but should have")
+	}
+
+	active.lastUsed = time.Now().Add(-TRANSACTION_TIMEOUT - time.Second)
+	manager.RemoveExpired()
+	if len(manager.transactions) != 0 {
+		t.Fatal("expired transactions should have been removed")
+	}
+}
+
+func TestTransactionScript(t *testing.T) {
+	database := NewDatabase("", &Table{Name: "table", Columns: []*Column{{Name: "col1", Type: TYPE_INT}}})
+	manager := NewTransactionManager(database)
+	operations, _ := ParseScript(Tokenize([]byte(`BEGIN; INSERT INTO "table" VALUES (1); COMMIT; INSERT INTO "table" VALUES ('a'); INSERT INTO "table" VALUES (2)`)))
+
+	results, transactionId, err := manager.ExecuteScript(operations, "", false)
+	if GetError(err).Statement != 4 || len(results) != 3 || transactionId != "" || len(database.tables[0].Columns[0].Values) != 1 {
+		t.Fatal("script should have committed the transaction and stopped at the fourth statement")
+	}
+
+	operations, _ = ParseScript(Tokenize([]byte(`INSERT INTO "table" VALUES (2); INSERT INTO "table" VALUES ('a')`)))
+	results, _, err = manager.ExecuteScript(operations, "", true)
+	if GetError(err).Statement != 2 || results != nil || len(database.tables[0].Columns[0].Values) != 1 {
+		t.Fatal("atomic script should not have saved any changes when a statement failed")
+	}
+
+	results, _, err = manager.ExecuteScript(operations[:1], "", true)
+	if err != nil || len(results) != 1 || len(database.tables[0].Columns[0].Values) != 2 {
+		t.Fatal("atomic script should have saved the changes")
+	}
+
+	operations, _ = ParseScript(Tokenize([]byte("BEGIN; COMMIT")))
+	_, _, err = manager.ExecuteScript(operations, "", true)
+	if err == nil {
+		t.Fatal("atomic script should not allow transaction statements")
+	}
+}
+
+func TestTransactionCommitCheckpoint(t *testing.T) {
+	rootPath := t.TempDir()
+	database := NewDatabase(rootPath)
+	database.Load()
+	database.Create("table", []ColData{{ColName: "col1", ColType: TYPE_INT}})
+	manager := NewTransactionManager(database)
+	transaction, _ := manager.Begin()
+	manager.Execute(&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("1")}}, transaction.Id)
+
+	database.log.records = CHECKPOINT_INTERVAL - 1
+	err := manager.Commit(transaction.Id)
+	if err != nil || database.log.records != 0 || database.dirty["table"] {
+		t.Fatal("commit should have saved the database when the write-ahead log was full")
+	}
+
+	loaded := NewDatabase(rootPath)
+	err = loaded.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := loaded.Get("table")
+	if err != nil || len(table.Columns[0].Values) != 1 {
+		t.Fatal("saved database should have the committed row")
+	}
+}
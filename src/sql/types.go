@@ -1,52 +1,99 @@
-package sql
-
-import (
-	"fmt"
-	"strings"
-)
-
-// Enum to represent all the possible datatypes in the database, values named with TYPE prefix
-type ColumnType int
-
-const (
-	// Represents an integer value, a whole number
-	TYPE_INT ColumnType = iota
-	// Represents a string value
-	TYPE_VARCHAR
-)
-
-// Get a datatype based of a string
-func GetType(s string) (ColumnType, error) {
-	switch strings.ToUpper(s) {
-	case "INT":
-		return TYPE_INT, nil
-	case "VARCHAR":
-		return TYPE_VARCHAR, nil
-	}
-
-	return -1, fmt.Errorf("invalid column type: %s", s)
-}
-
-// Get a default value of a datatype
-func (Type ColumnType) GetDefaultValue() (string, error) {
-	switch Type {
-	case TYPE_INT:
-		return "0", nil
-	case TYPE_VARCHAR:
-		return "", nil
-	}
-
-	return "", fmt.Errorf("column type does not have default value: %s", Type.ToString())
-}
-
-// Get a string value of a datatype
-func (Type ColumnType) ToString() string {
-	switch Type {
-	case TYPE_INT:
-		return "INT"
-	case TYPE_VARCHAR:
-		return "VARCHAR"
-	}
-
-	return "NULL"
-}
+package sql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Enum to represent all the possible datatypes in the database, values named with TYPE prefix
+type ColumnType int
+
+const (
+	// Represents an integer value, a whole number
+	TYPE_INT ColumnType = iota
+	// Represents a string value
+	TYPE_VARCHAR
+	// Represents a floating point value, a decimal number
+	TYPE_FLOAT
+)
+
+// Get a datatype based of a string
+func GetType(s string) (ColumnType, error) {
+	switch strings.ToUpper(s) {
+	case "INT":
+		return TYPE_INT, nil
+	case "VARCHAR":
+		return TYPE_VARCHAR, nil
+	case "FLOAT":
+		return TYPE_FLOAT, nil
+	}
+
+	return -1, fmt.Errorf("invalid column type: %s", s)
+}
+
+// Get a default value of a datatype
+func (Type ColumnType) GetDefaultValue() (string, error) {
+	switch Type {
+	case TYPE_INT, TYPE_FLOAT:
+		return "0", nil
+	case TYPE_VARCHAR:
+		return "", nil
+	}
+
+	return "", fmt.Errorf("column type does not have default value: %s", Type.ToString())
+}
+
+// Get a string value of a datatype
+func (Type ColumnType) ToString() string {
+	switch Type {
+	case TYPE_INT:
+		return "INT"
+	case TYPE_VARCHAR:
+		return "VARCHAR"
+	case TYPE_FLOAT:
+		return "FLOAT"
+	}
+
+	return "NULL"
+}
+
+// Convert a value to the datatype, the value is normalized to the representation of the datatype.
+// Returns an error if the value cannot be represented by the datatype, null values are valid for every datatype
+func (Type ColumnType) Coerce(value Value) (Value, error) {
+	if value.IsNull() {
+		return value, nil
+	}
+
+	switch Type {
+	case TYPE_INT:
+		intValue, err := strconv.Atoi(value.String)
+		if err != nil {
+			return Value{}, newError(ERROR_TYPE, "'%s' is not a valid integer", value.String)
+		}
+
+		return NewValue(strconv.Itoa(intValue)), nil
+	case TYPE_FLOAT:
+		floatValue, err := strconv.ParseFloat(value.String, 64)
+		if err != nil || math.IsInf(floatValue, 0) || math.IsNaN(floatValue) {
+			return Value{}, newError(ERROR_TYPE, "'%s' is not a valid decimal number", value.String)
+		}
+
+		return NewValue(FormatFloat(floatValue)), nil
+	case TYPE_VARCHAR:
+		return value, nil
+	}
+
+	return Value{}, fmt.Errorf("invalid column type: %s", Type.ToString())
+}
+
+// Check if a datatype is a number
+func (Type ColumnType) IsNumeric() bool {
+	return Type == TYPE_INT || Type == TYPE_FLOAT
+}
+
+// Format a float to the shortest string representation that reads back to the same value
+func FormatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
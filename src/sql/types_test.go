@@ -1,30 +1,52 @@
-package sql
-
-import (
-	"strings"
-	"testing"
-)
-
-func TestColumnTypeGetDefaultValue(t *testing.T) {
-	val, err := TYPE_INT.GetDefaultValue()
-	if val != "0" || err != nil {
-		t.Fatal("wrong default value configured, expected=0")
-	}
-
-	val, err = TYPE_VARCHAR.GetDefaultValue()
-	if val != "" || err != nil {
-		t.Fatal("wrong default value configured, expected empty string")
-	}
-}
-
-func TestColumnTypeToString(t *testing.T) {
-	val := TYPE_INT.ToString()
-	if strings.ToUpper(val) != "INT" {
-		t.Fatal("wrong to_string value configured, expected int")
-	}
-
-	val = TYPE_VARCHAR.ToString()
-	if strings.ToUpper(val) != "VARCHAR" {
-		t.Fatal("wrong to_string value configured, expected varchar")
-	}
-}
+package sql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestColumnTypeGetDefaultValue(t *testing.T) {
+	val, err := TYPE_INT.GetDefaultValue()
+	if val != "0" || err != nil {
+		t.Fatal("wrong default value configured, expected=0")
+	}
+
+	val, err = TYPE_VARCHAR.GetDefaultValue()
+	if val != "" || err != nil {
+		t.Fatal("wrong default value configured, expected empty string")
+	}
+}
+
+func TestColumnTypeToString(t *testing.T) {
+	val := TYPE_INT.ToString()
+	if strings.ToUpper(val) != "INT" {
+		t.Fatal("wrong to_string value configured, expected int")
+	}
+
+	val = TYPE_VARCHAR.ToString()
+	if strings.ToUpper(val) != "VARCHAR" {
+		t.Fatal("wrong to_string value configured, expected varchar")
+	}
+}
+
+func TestColumnTypeCoerce(t *testing.T) {
+	val, err := TYPE_INT.Coerce(NewValue("007"))
+	if val.String != "7" || err != nil {
+		t.Fatal("wrong coerced value, expected=7")
+	}
+
+	_, err = TYPE_INT.Coerce(NewValue("abc"))
+	if err == nil {
+		t.Fatal("error was not thrown but should have")
+	}
+
+	val, err = TYPE_FLOAT.Coerce(NewValue("01.50"))
+	if val.String != "1.5" || err != nil {
+		t.Fatal("wrong coerced value, expected=1.5")
+	}
+
+	val, err = TYPE_INT.Coerce(Value{})
+	if !val.IsNull() || err != nil {
+		t.Fatal("null should be valid for every type")
+	}
+}
@@ -1,23 +1,34 @@
-package sql
-
-// Contruct a new array by proc
-func Map[TIn, TOut any](in []TIn, proc func(TIn) TOut) []TOut {
-	out := make([]TOut, len(in))
-	for i, v := range in {
-		out[i] = proc(v)
-	}
-
-	return out
-}
-
-//
-// func Filter[T any](in []T, match func(T) bool) []T {
-// 	out := make([]T, 0)
-// 	for _, v := range in {
-// 		if match(v) {
-// 			out = append(out, v)
-// 		}
-// 	}
-
-// 	return out
-// }
+package sql
+
+// Contruct a new array by proc
+func Map[TIn, TOut any](in []TIn, proc func(TIn) TOut) []TOut {
+	out := make([]TOut, len(in))
+	for i, v := range in {
+		out[i] = proc(v)
+	}
+
+	return out
+}
+
+// Check if match returns true for any value
+func IsTrueForAny[T any](in []T, match func(T) bool) bool {
+	for _, v := range in {
+		if match(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// func Filter[T any](in []T, match func(T) bool) []T {
+// 	out := make([]T, 0)
+// 	for _, v := range in {
+// 		if match(v) {
+// 			out = append(out, v)
+// 		}
+// 	}
+
+// 	return out
+// }
@@ -0,0 +1,56 @@
+package sql
+
+import (
+	"encoding/json"
+)
+
+// Represents a single value in the database, which may be null.
+// Zero value is null
+type Value struct {
+	String string // Value as a string, empty if null
+	Valid  bool   // False if the value is null
+}
+
+// Create a new non null value
+func NewValue(s string) Value {
+	return Value{String: s, Valid: true}
+}
+
+// Create a new array of non null values
+func NewValues(values ...string) []Value {
+	return Map(values, NewValue)
+}
+
+// Check if the value is null
+func (value Value) IsNull() bool {
+	return !value.Valid
+}
+
+// Get a string value of the value, null values are NULL
+func (value Value) ToString() string {
+	if value.IsNull() {
+		return "NULL"
+	}
+
+	return value.String
+}
+
+// Write the value as a json string, null values are written as null
+func (value Value) MarshalJSON() ([]byte, error) {
+	if value.IsNull() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(value.String)
+}
+
+// Read the value from a json string or null
+func (value *Value) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*value = Value{}
+		return nil
+	}
+
+	value.Valid = true
+	return json.Unmarshal(data, &value.String)
+}
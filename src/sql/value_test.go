@@ -0,0 +1,19 @@
+package sql
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValueJSON(t *testing.T) {
+	bytes, err := json.Marshal([]Value{NewValue("1"), {}})
+	if err != nil || string(bytes) != `["1",null]` {
+		t.Fatalf("wrong json, expected=[\"1\",null], got=%s", string(bytes))
+	}
+
+	values := []Value{}
+	err = json.Unmarshal(bytes, &values)
+	if err != nil || len(values) != 2 || values[0] != NewValue("1") || !values[1].IsNull() {
+		t.Fatal("values were not read back from json")
+	}
+}
@@ -0,0 +1,254 @@
+package sql
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+)
+
+// Name of the write-ahead log file in the database directory
+const WAL_FILE_NAME = "wal.log"
+
+// Number of records written to the write-ahead log before the tables are saved and the log is emptied
+const CHECKPOINT_INTERVAL = 1000
+
+// Enum to represent the type of a change, values are prefixed with CHANGE.
+type ChangeType int
+
+const (
+	CHANGE_CREATE       ChangeType = 0 // Table is created
+	CHANGE_DROP         ChangeType = 1 // Table is deleted
+	CHANGE_INSERT       ChangeType = 2 // Rows are appended to a table
+	CHANGE_UPDATE       ChangeType = 3 // Values of some columns are replaced in rows of a table
+	CHANGE_DELETE       ChangeType = 4 // Rows are deleted from a table
+	CHANGE_CREATE_INDEX ChangeType = 5 // Index is added to a table
+	CHANGE_DROP_INDEX   ChangeType = 6 // Index is removed from a table
+)
+
+// A single change to the data of the database.
+// Changes contain the resulting values so applying the same changes again always gives the same data
+type Change struct {
+	Type        ChangeType `json:"type"`
+	Table       string     `json:"table"`                  // Name of the changed table
+	Columns     []*Column  `json:"columns,omitempty"`      // Columns of a created table
+	ColumnNames []string   `json:"column_names,omitempty"` // Names of the updated columns
+	Indexes     []int      `json:"indexes,omitempty"`      // Indexes of the updated rows or the deleted rows in the order of deletion
+	Rows        [][]Value  `json:"rows,omitempty"`         // Inserted rows or the new values of the updated rows
+	Index       *Index     `json:"index,omitempty"`        // Created index or the name of the dropped index
+	undo        func()     // Reverts the change in memory if the change could not be recorded, nil if there is nothing to revert
+}
+
+// Revert changes in memory in the reverse order of the changes
+func revertChanges(changes []*Change) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].undo != nil {
+			changes[i].undo()
+		}
+	}
+}
+
+// Apply a change to the tables of a database, the database must be locked for writing.
+// Indexes are not updated, they are built after every change is applied
+func (change *Change) apply(database *Database) error {
+	if change.Type == CHANGE_CREATE {
+		database.tables = append(database.tables, &Table{Name: change.Table, Columns: change.Columns})
+		return nil
+	}
+
+	index := slices.IndexFunc(database.tables, func(t *Table) bool { return t.Name == change.Table })
+	if index == -1 {
+		return fmt.Errorf("table not found: %s", change.Table)
+	}
+
+	table := database.tables[index]
+	switch change.Type {
+	case CHANGE_DROP:
+		database.tables = slices.Delete(database.tables, index, index+1)
+	case CHANGE_INSERT:
+		for colIndex, col := range table.Columns {
+			for _, row := range change.Rows {
+				col.Values = append(col.Values, row[colIndex])
+			}
+		}
+	case CHANGE_UPDATE:
+		columns, err := table.getColumns(change.ColumnNames)
+		if err != nil {
+			return err
+		}
+
+		for i, rowIndex := range change.Indexes {
+			for colIndex, col := range columns {
+				col.Values[rowIndex] = change.Rows[i][colIndex]
+			}
+		}
+	case CHANGE_DELETE:
+		for _, rowIndex := range change.Indexes {
+			for _, col := range table.Columns {
+				col.Values = slices.Delete(col.Values, rowIndex, rowIndex+1)
+			}
+		}
+	case CHANGE_CREATE_INDEX:
+		table.Indexes = append(table.Indexes, change.Index)
+	case CHANGE_DROP_INDEX:
+		table.removeIndex(change.Index.Name)
+	}
+
+	return nil
+}
+
+// A single record in the write-ahead log, contains the changes of one operation or one transaction
+type LogRecord struct {
+	Sequence int64     `json:"sequence"` // Increasing number of the record
+	Changes  []*Change `json:"changes"`
+}
+
+// Append-only log of the changes made to the database.
+// Every record is flushed to the disk before the change is reported done, the log is emptied when the tables are saved
+type WriteAheadLog struct {
+	file     *os.File
+	sequence int64 // Sequence of the last record written
+	records  int   // Number of records since the log was emptied
+	err      error // Error that left an incomplete record in the file, every append fails until the log is emptied
+}
+
+// Open the write-ahead log at a file path for appending, the file is created if it does not exist.
+// Returns the records with a greater sequence than afterSequence.
+// An incomplete last record left by a crash is removed from the file
+func openWriteAheadLog(filePath string, afterSequence int64) (*WriteAheadLog, []*LogRecord, error) {
+	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, fs.ModePerm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log := &WriteAheadLog{file: file, sequence: afterSequence}
+	records, length, err := readLogRecords(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	err = file.Truncate(length)
+	if err == nil {
+		_, err = file.Seek(length, io.SeekStart)
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	log.records = len(records)
+	records = slices.DeleteFunc(records, func(record *LogRecord) bool { return record.Sequence <= afterSequence })
+	if len(records) > 0 {
+		log.sequence = records[len(records)-1].Sequence
+	}
+
+	return log, records, nil
+}
+
+// Read every complete record from a log file.
+// Returns the records and the length of the file up to the end of the last complete record
+func readLogRecords(file *os.File) ([]*LogRecord, int64, error) {
+	reader := bufio.NewReader(file)
+	records := []*LogRecord{}
+	length := int64(0)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return records, length, nil
+		}
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		record := &LogRecord{}
+		err = json.Unmarshal(bytes.TrimSpace(line), record)
+		if err != nil {
+			if _, peekErr := reader.Peek(1); errors.Is(peekErr, io.EOF) {
+				return records, length, nil
+			}
+
+			return nil, 0, fmt.Errorf("write-ahead log is corrupted after %d records: %w", len(records), err)
+		}
+
+		records = append(records, record)
+		length += int64(len(line))
+	}
+}
+
+// Write the changes as a single record and flush it to the disk.
+// A record that could not be written completely is removed, so that the next record is not written after it
+func (log *WriteAheadLog) append(changes []*Change) error {
+	if log.err != nil {
+		return fmt.Errorf("write-ahead log cannot be written, %w", log.err)
+	}
+
+	record := &LogRecord{Sequence: log.sequence + 1, Changes: changes}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	length, err := log.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	_, err = log.file.Write(append(data, '\n'))
+	if err == nil {
+		err = log.file.Sync()
+	}
+
+	if err != nil {
+		truncateErr := log.file.Truncate(length)
+		if truncateErr == nil {
+			_, truncateErr = log.file.Seek(length, io.SeekStart)
+		}
+
+		if truncateErr != nil {
+			log.err = truncateErr
+		}
+
+		return errors.Join(err, truncateErr)
+	}
+
+	log.sequence = record.Sequence
+	log.records++
+	return nil
+}
+
+// Check if the log has enough records to save the tables and empty the log
+func (log *WriteAheadLog) isFull() bool {
+	return log.records >= CHECKPOINT_INTERVAL
+}
+
+// Remove every record from the log, called after the tables are saved
+func (log *WriteAheadLog) clear() error {
+	err := log.file.Truncate(0)
+	if err == nil {
+		_, err = log.file.Seek(0, io.SeekStart)
+	}
+
+	if err == nil {
+		err = log.file.Sync()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	log.records, log.err = 0, nil
+	return nil
+}
+
+// Close the log file
+func (log *WriteAheadLog) close() error {
+	return log.file.Close()
+}
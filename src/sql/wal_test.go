@@ -0,0 +1,122 @@
+package sql
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteAheadLogReplay(t *testing.T) {
+	rootPath := t.TempDir()
+	database := NewDatabase(rootPath)
+	err := database.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	database.Create("table", []ColData{{ColName: "col1", ColType: TYPE_INT}, {ColName: "col2", ColType: TYPE_VARCHAR}})
+	operations := []Operation{
+		&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("1", "a"), NewValues("2", "b"), NewValues("3", "c")}},
+		&UpdateOperation{TableName: "table", Assignments: []*Assignment{{ColumnName: "col2", Expression: &LiteralExpression{Value: NewValue("d")}}}, Condition: &Filter{ColumnName: "col1", Operator: EQUAL, CompareValue: NewValue("2")}},
+		&DeleteOperation{TableName: "table", Condition: &Filter{ColumnName: "col1", Operator: EQUAL, CompareValue: NewValue("1")}},
+	}
+
+	for _, operation := range operations {
+		_, err = operation.Call(database)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, _ := os.OpenFile(filepath.Join(rootPath, WAL_FILE_NAME), os.O_APPEND|os.O_WRONLY, fs.ModePerm)
+	file.WriteString(`{"sequence": 5, "chan`)
+	file.Close()
+
+	loaded := NewDatabase(rootPath)
+	err = loaded.Load()
+	if err != nil {
+		t.Fatal("load should have ignored the incomplete record")
+	}
+
+	table, err := loaded.Get("table")
+	if err != nil || len(table.Columns[0].Values) != 2 || table.Columns[1].Values[0].String != "d" {
+		t.Fatal("load should have replayed the changes in the write-ahead log")
+	}
+}
+
+func TestWriteAheadLogCheckpoint(t *testing.T) {
+	rootPath := t.TempDir()
+	database := NewDatabase(rootPath)
+	database.Load()
+	database.Create("table", []ColData{{ColName: "col1", ColType: TYPE_INT}})
+	(&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("1")}}).Call(database)
+
+	filePath := filepath.Join(rootPath, WAL_FILE_NAME)
+	data, _ := os.ReadFile(filePath)
+	err := database.Save()
+	if err != nil {
+		t.Fatal("save returned an error but should not have")
+	}
+
+	os.WriteFile(filePath, data, fs.ModePerm)
+	loaded := NewDatabase(rootPath)
+	err = loaded.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := loaded.Get("table")
+	if err != nil || len(table.Columns[0].Values) != 1 {
+		t.Fatal("load should not have replayed the records included in the saved tables")
+	}
+}
+
+func TestWriteAheadLogFailure(t *testing.T) {
+	database := NewDatabase(t.TempDir())
+	database.Load()
+	database.Create("table", []ColData{{ColName: "col1", ColType: TYPE_INT}})
+	database.CreateIndex("table", &Index{Name: "index", ColumnNames: []string{"col1"}})
+	(&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("1"), NewValues("2"), NewValues("3")}}).Call(database)
+
+	manager := NewTransactionManager(database)
+	transaction, _ := manager.Begin()
+	manager.Execute(&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("4")}}, transaction.Id)
+
+	// WRITES TO A CLOSED FILE FAIL
+	database.log.file.Close()
+	operations := []Operation{
+		&InsertOperation{TableName: "table", Rows: [][]Value{NewValues("4")}},
+		&UpdateOperation{TableName: "table", Assignments: []*Assignment{{ColumnName: "col1", Expression: &LiteralExpression{Value: NewValue("5")}}}, Condition: &Filter{ColumnName: "col1", Operator: EQUAL, CompareValue: NewValue("2")}},
+		&DeleteOperation{TableName: "table", Condition: &Filter{ColumnName: "col1", Operator: LESS, CompareValue: NewValue("3")}},
+	}
+
+	for _, operation := range operations {
+		if _, err := operation.Call(database); err == nil {
+			t.Fatalf("%T should have failed when the log could not be written", operation)
+		}
+	}
+
+	if err := manager.Commit(transaction.Id); err == nil {
+		t.Fatal("commit should have failed when the log could not be written")
+	}
+
+	if err := database.Create("other", []ColData{{ColName: "col1", ColType: TYPE_INT}}); err == nil || len(database.tables) != 1 {
+		t.Fatal("create should have failed without adding the table when the log could not be written")
+	}
+
+	if err := database.DeleteIndex("index"); err == nil {
+		t.Fatal("delete index should have failed when the log could not be written")
+	}
+
+	table, _ := database.Get("table")
+	if !reflect.DeepEqual(table.Columns[0].Values, NewValues("1", "2", "3")) {
+		t.Fatalf("failed changes should have been reverted, got %v", table.Columns[0].Values)
+	}
+
+	item := table.Indexes[0].tree.find(NewValues("2"))
+	if len(table.Indexes) != 1 || item == nil || !reflect.DeepEqual(item.rows, []int{1}) {
+		t.Fatal("index should have been built from the reverted rows")
+	}
+}